@@ -0,0 +1,75 @@
+package httpz
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// asyncPool bounds the requests sent by [Client.Go] to a number of concurrent
+// goroutines, waited for by [Client.Close].
+type asyncPool struct {
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	slots  chan struct{}
+	closed bool
+}
+
+func newAsyncPool(workers int) *asyncPool {
+	return &asyncPool{slots: make(chan struct{}, workers)}
+}
+
+// Go sends a best-effort request in the background, e.g. a telemetry event,
+// logged and traced as usual but its response is discarded, a failure is only
+// logged. The request keeps the values of ctx but not its cancellation, so it
+// outlives the caller. It blocks while the [WithAsyncWorkers] requests are in
+// flight and drops the request once the client is closed, see [Client.Close].
+func (c *Client) Go(ctx context.Context, method, path string, body any) {
+	p := c.async
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		c.cfg.logger.WarnContext(ctx, "[HTTPZ] async request dropped, client closed",
+			slog.String("method", method),
+			slog.String("path", path),
+		)
+		return
+	}
+	p.wg.Add(1)
+	p.mu.Unlock()
+
+	p.slots <- struct{}{}
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.slots }()
+
+		ctx := context.WithoutCancel(ctx)
+		req := c.NewRequest(ctx)
+		if body != nil {
+			req.SetBody(body)
+		}
+		if _, err := req.Execute(method, path); err != nil {
+			requestLogger(ctx, c.cfg).WarnContext(ctx, "[HTTPZ] async request failed",
+				slog.String("method", method),
+				slog.String("path", path),
+				slog.String("error", err.Error()),
+			)
+		}
+	}()
+}
+
+// Close waits for the requests sent by [Client.Go] to complete, dropping the
+// later ones, then closes the resty client. It's safe to call more than once.
+func (c *Client) Close() error {
+	p := c.async
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return c.Client.Close()
+}
@@ -0,0 +1,56 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGo(t *testing.T) {
+	var received, inflight atomic.Int32
+	var overflow atomic.Bool
+	server := startTestServer(t, testHandler{
+		method: http.MethodPost,
+		path:   "/test/async/events",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if inflight.Add(1) > 2 {
+				overflow.Store(true)
+			}
+			defer inflight.Add(-1)
+			var event map[string]string
+			if json.NewDecoder(r.Body).Decode(&event) == nil && event["type"] == "click" {
+				time.Sleep(10 * time.Millisecond)
+				received.Add(1)
+			}
+			w.WriteHeader(http.StatusAccepted)
+		},
+	})
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithAsyncWorkers(2),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	for range 6 {
+		client.Go(ctx, http.MethodPost, "/test/async/events", map[string]string{"type": "click"})
+	}
+	cancel()
+
+	assert.NoError(t, client.Close())
+	assert.Equal(t, int32(6), received.Load(), "awaited by Close despite the canceled context")
+	assert.False(t, overflow.Load(), "more requests in flight than workers")
+
+	client.Go(context.Background(), http.MethodPost, "/test/async/events", map[string]string{"type": "click"})
+
+	assert.NoError(t, client.Close())
+	assert.Equal(t, int32(6), received.Load())
+	assert.Contains(t, b.String(), "[HTTPZ] async request dropped, client closed")
+}
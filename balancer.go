@@ -0,0 +1,227 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"resty.dev/v3"
+)
+
+// LoadBalancingStrategy picks the base URL of a request among the ones of
+// [WithBaseURLs].
+type LoadBalancingStrategy int
+
+const (
+	// RoundRobin picks the base URLs in turn.
+	RoundRobin LoadBalancingStrategy = iota
+	// Random picks a base URL at random.
+	Random
+)
+
+// balancer spreads the requests over the base URLs of [WithBaseURLs] or
+// [WithWeightedBaseURLs], a base URL failing failureThreshold times in a row is
+// ejected for ejectFor.
+type balancer struct {
+	mu               sync.Mutex
+	endpoints        []*endpoint
+	strategy         LoadBalancingStrategy
+	weighted         bool
+	rand             *rand.Rand
+	next             int
+	failureThreshold uint32
+	ejectFor         time.Duration
+	policies         []func(*http.Response) bool
+}
+
+type endpoint struct {
+	baseURL      string
+	weight       int
+	failures     uint32
+	ejectedUntil time.Time
+}
+
+// newBalancer returns the balancer of [WithBaseURLs], ejecting with the
+// [WithCircuitBreaker] settings or their defaults.
+func newBalancer(cfg *config) *balancer {
+	b := &balancer{
+		strategy:         cfg.lbStrategy,
+		failureThreshold: cfg.cbFailureThreshold,
+		ejectFor:         cfg.cbTimeout,
+		policies:         cfg.cbPolicies,
+		weighted:         cfg.baseURLWeights != nil,
+		rand:             rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+	if b.failureThreshold == 0 {
+		b.failureThreshold = 3
+	}
+	if b.ejectFor <= 0 {
+		b.ejectFor = 10 * time.Second
+	}
+	if len(b.policies) == 0 {
+		b.policies = []func(*http.Response) bool{PolicyServerAndRateLimit}
+	}
+	for _, u := range cfg.baseURLs {
+		weight := 1
+		if b.weighted {
+			weight = cfg.baseURLWeights[u]
+		}
+		b.endpoints = append(b.endpoints, &endpoint{baseURL: strings.TrimRight(u, "/"), weight: weight})
+	}
+	return b
+}
+
+// pick returns a base URL which isn't ejected, or any base URL when they all
+// are, a request to a maybe recovered one beats failing it.
+func (b *balancer) pick(now time.Time) *endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	healthy := make([]*endpoint, 0, len(b.endpoints))
+	for _, e := range b.endpoints {
+		if !now.Before(e.ejectedUntil) {
+			healthy = append(healthy, e)
+		}
+	}
+	if len(healthy) == 0 {
+		healthy = b.endpoints
+	}
+
+	switch {
+	case b.weighted:
+		return pickWeighted(b.rand, healthy)
+	case b.strategy == Random:
+		return healthy[b.rand.IntN(len(healthy))]
+	}
+	b.next++
+	return healthy[(b.next-1)%len(healthy)]
+}
+
+// pickWeighted picks an endpoint at random in proportion to its weight.
+func pickWeighted(r *rand.Rand, endpoints []*endpoint) *endpoint {
+	total := 0
+	for _, e := range endpoints {
+		total += e.weight
+	}
+	n := r.IntN(total)
+	for _, e := range endpoints {
+		if n < e.weight {
+			return e
+		}
+		n -= e.weight
+	}
+	return endpoints[len(endpoints)-1]
+}
+
+func (b *balancer) report(e *endpoint, failed bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		e.failures = 0
+		return
+	}
+	e.failures++
+	if e.failures >= b.failureThreshold {
+		e.failures = 0
+		e.ejectedUntil = now.Add(b.ejectFor)
+	}
+}
+
+// isFailure reports whether res is a failure by the circuit breaker policies.
+func (b *balancer) isFailure(res *http.Response) bool {
+	for _, p := range b.policies {
+		if p(res) {
+			return true
+		}
+	}
+	return false
+}
+
+type balancerCtxKey struct{}
+
+// balancerAttempt is the base URL picked for an attempt, its outcome is
+// reported once whichever middleware or hook sees it first.
+type balancerAttempt struct {
+	endpoint *endpoint
+	once     sync.Once
+}
+
+func (a *balancerAttempt) report(b *balancer, failed bool) {
+	a.once.Do(func() {
+		b.report(a.endpoint, failed, time.Now())
+	})
+}
+
+func balancerAttemptFrom(ctx context.Context) (*balancerAttempt, bool) {
+	a, ok := ctx.Value(balancerCtxKey{}).(*balancerAttempt)
+	return a, ok
+}
+
+// pickBaseURL prefixes the relative URL of every attempt with the base URL
+// picked by the balancer, a retry may then go to another base URL. It runs
+// after the middlewares looking up the path name of the URL.
+func pickBaseURL(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.balancer == nil || strings.HasPrefix(req.URL, "http://") || strings.HasPrefix(req.URL, "https://") {
+			return nil
+		}
+
+		e := cfg.balancer.pick(time.Now())
+		req.SetContext(context.WithValue(req.Context(), balancerCtxKey{}, &balancerAttempt{endpoint: e}))
+		req.URL = e.baseURL + normalizePath("/"+req.URL)
+
+		return nil
+	}
+}
+
+func reportBaseURLResponse(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		if cfg.balancer == nil || res.RawResponse == nil {
+			return nil
+		}
+
+		if a, ok := balancerAttemptFrom(res.Request.Context()); ok {
+			a.report(cfg.balancer, cfg.balancer.isFailure(res.RawResponse))
+		}
+
+		return nil
+	}
+}
+
+// reportBaseURLRetry reports an attempt failing without a response before it's
+// retried.
+func reportBaseURLRetry(cfg *config) resty.RetryHookFunc {
+	return func(res *resty.Response, _ error) {
+		if cfg.balancer == nil || res == nil || res.RawResponse != nil {
+			return
+		}
+
+		if a, ok := balancerAttemptFrom(res.Request.Context()); ok {
+			a.report(cfg.balancer, true)
+		}
+	}
+}
+
+// reportBaseURLError reports the last attempt failing without a response, an
+// attempt with a response is already reported, even when a response
+// middleware failed it, e.g. decoding a 200.
+func reportBaseURLError(cfg *config) resty.ErrorHook {
+	return func(req *resty.Request, err error) {
+		if cfg.balancer == nil {
+			return
+		}
+		var resErr *resty.ResponseError
+		if errors.As(err, &resErr) && resErr.Response != nil && resErr.Response.RawResponse != nil {
+			return
+		}
+
+		if a, ok := balancerAttemptFrom(req.Context()); ok {
+			a.report(cfg.balancer, true)
+		}
+	}
+}
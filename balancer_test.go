@@ -0,0 +1,133 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"resty.dev/v3"
+)
+
+func TestBaseURLs(t *testing.T) {
+	var hitsA, hitsB atomic.Int32
+	newServer := func(hits *atomic.Int32, status *atomic.Int32) string {
+		return startTestServer(t, testHandler{
+			method: http.MethodGet,
+			path:   "/users/1",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				w.WriteHeader(int(status.Load()))
+			},
+		}).URL
+	}
+	var statusA, statusB atomic.Int32
+	statusA.Store(http.StatusOK)
+	statusB.Store(http.StatusOK)
+	urlA, urlB := newServer(&hitsA, &statusA), newServer(&hitsB, &statusB)
+	client := NewClient("test-client", "",
+		WithPaths(map[string]string{"getUser": "/users/{id}"}),
+		WithBaseURLs([]string{urlA, urlB + "/"}),
+		WithCircuitBreaker(time.Minute, 2, 1),
+	)
+	get := func() {
+		t.Helper()
+		_, err := client.NewRequest(context.Background()).
+			SetPathParam("id", "1").
+			Get(client.GetPath("getUser"))
+		require.NoError(t, err)
+	}
+
+	for range 4 {
+		get()
+	}
+	assert.Equal(t, int32(2), hitsA.Load())
+	assert.Equal(t, int32(2), hitsB.Load())
+
+	statusB.Store(http.StatusServiceUnavailable)
+	hitsA.Store(0)
+	hitsB.Store(0)
+	for range 8 {
+		get()
+	}
+	assert.Equal(t, int32(2), hitsB.Load(), "ejected after 2 failures")
+	assert.Equal(t, int32(6), hitsA.Load())
+}
+
+func TestBalancerRandom(t *testing.T) {
+	b := newBalancer(&config{
+		baseURLs:   []string{"http://a", "http://b"},
+		lbStrategy: Random,
+	})
+	picked := map[string]int{}
+
+	for range 100 {
+		picked[b.pick(time.Now()).baseURL]++
+	}
+
+	assert.Len(t, picked, 2)
+}
+
+func TestBalancerAllEjected(t *testing.T) {
+	b := newBalancer(&config{baseURLs: []string{"http://a"}, cbFailureThreshold: 1})
+	now := time.Now()
+
+	e := b.pick(now)
+	b.report(e, true, now)
+
+	assert.Equal(t, "http://a", b.pick(now).baseURL)
+}
+
+func TestBalancerWeighted(t *testing.T) {
+	cfg := &config{}
+	WithWeightedBaseURLs(map[string]int{"http://stable": 90, "http://canary": 10, "http://off": 0})(cfg)
+	b := newBalancer(cfg)
+	b.rand = rand.New(rand.NewPCG(1, 2))
+	picked := map[string]int{}
+
+	for range 10_000 {
+		picked[b.pick(time.Now()).baseURL]++
+	}
+
+	assert.Equal(t, []string{"http://canary", "http://stable"}, cfg.baseURLs)
+	assert.InDelta(t, 9_000, picked["http://stable"], 300)
+	assert.InDelta(t, 1_000, picked["http://canary"], 300)
+	assert.Zero(t, picked["http://off"])
+}
+
+func TestReportBaseURLError(t *testing.T) {
+	cfg := &config{baseURLs: []string{"http://a"}}
+	cfg.balancer = newBalancer(cfg)
+	newRequest := func() (*resty.Request, *endpoint) {
+		e := cfg.balancer.pick(time.Now())
+		ctx := context.WithValue(context.Background(), balancerCtxKey{}, &balancerAttempt{endpoint: e})
+		return resty.New().R().SetContext(ctx), e
+	}
+
+	t.Run("response failed by a middleware is not reported", func(t *testing.T) {
+		req, e := newRequest()
+
+		reportBaseURLError(cfg)(req, &resty.ResponseError{
+			Response: &resty.Response{Request: req, RawResponse: &http.Response{StatusCode: http.StatusOK}},
+			Err:      errors.New("decode response"),
+		})
+
+		assert.Zero(t, e.failures)
+	})
+
+	t.Run("no response is reported", func(t *testing.T) {
+		req, e := newRequest()
+
+		reportBaseURLError(cfg)(req, &resty.ResponseError{
+			Response: &resty.Response{Request: req},
+			Err:      errors.New("connection refused"),
+		})
+
+		assert.Equal(t, uint32(1), e.failures)
+	})
+}
@@ -0,0 +1,241 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"resty.dev/v3"
+)
+
+// ErrNotStruct is returned by the struct binding helpers when the given value
+// isn't a struct or a pointer to a struct.
+var ErrNotStruct = errors.New("httpz: value must be a struct or a pointer to a struct")
+
+// ErrMissingPathParam is returned by [SetPathParamsFromStruct] and
+// [Client.NewRequestFromParams] when a {name} placeholder of the path is left
+// unbound.
+var ErrMissingPathParam = errors.New("httpz: missing path param")
+
+// SetQueryParamsFromStruct sets query params on req from the fields of v tagged
+// with `query:"name"`. Fields tagged with `query:"name,omitempty"` are skipped
+// when zero, nil pointer fields are always skipped, fields tagged with
+// `query:"-"` or without a tag are ignored, and slice or array fields are added
+// as repeated params.
+//
+//	type ListUsersParams struct {
+//		Status []string `query:"status"`
+//		Page   int      `query:"page,omitempty"`
+//	}
+func SetQueryParamsFromStruct(req *resty.Request, v any) error {
+	values := url.Values{}
+	err := walkTaggedFields(v, "query", func(name string, omitEmpty bool, fv reflect.Value) error {
+		if omitEmpty && fv.IsZero() || isNilPointer(fv) {
+			return nil
+		}
+		fv = reflect.Indirect(fv)
+		if fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array {
+			for i := range fv.Len() {
+				values.Add(name, formatValue(fv.Index(i)))
+			}
+			return nil
+		}
+		values.Add(name, formatValue(fv))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	req.SetQueryParamsFromValues(values)
+
+	return nil
+}
+
+// SetPathParamsFromStruct sets path params on req from the fields of v tagged
+// with `path:"name[,omitempty]"`, filling the {name} placeholders of the path
+// template. Like [SetQueryParamsFromStruct], a zero field is bound unless
+// omitempty is set, and a nil pointer field is skipped.
+//
+// When the URL of req is already set, a {name} placeholder bound neither by v
+// nor by the path params of req returns [ErrMissingPathParam]. The client path
+// params aren't visible to req, use [Client.NewRequestFromParams] to bind
+// against them too.
+//
+//	type GetPostParams struct {
+//		UserID string `path:"id"`
+//		PostID int    `path:"postId"`
+//	}
+//
+//	req := client.NewRequest(ctx).SetURL(client.GetPath("getPost"))
+//	if err := httpz.SetPathParamsFromStruct(req, &params); err != nil {
+//		return nil, err
+//	}
+func SetPathParamsFromStruct(req *resty.Request, v any) error {
+	if err := bindPathParams(req, v); err != nil {
+		return err
+	}
+	if name, ok := unboundPathParam(req.URL, req.PathParams); ok {
+		return fmt.Errorf("%w: %s", ErrMissingPathParam, name)
+	}
+
+	return nil
+}
+
+// bindPathParams sets the path params of [SetPathParamsFromStruct] on req
+// without checking the placeholders of its URL.
+func bindPathParams(req *resty.Request, v any) error {
+	params := make(map[string]string)
+	err := walkTaggedFields(v, "path", func(name string, omitEmpty bool, fv reflect.Value) error {
+		if omitEmpty && fv.IsZero() || isNilPointer(fv) {
+			return nil
+		}
+		params[name] = formatValue(fv)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	req.SetPathParams(params)
+
+	return nil
+}
+
+// SetHeadersFromStruct sets headers on req from the fields of v tagged with
+// `header:"Name"`, following the same rules as [SetQueryParamsFromStruct], e.g.
+// a nil pointer field sets no header.
+//
+//	type TenantHeaders struct {
+//		TenantID string `header:"X-Tenant-Id"`
+//		Locale   string `header:"Accept-Language,omitempty"`
+//	}
+func SetHeadersFromStruct(req *resty.Request, v any) error {
+	return walkTaggedFields(v, "header", func(name string, omitEmpty bool, fv reflect.Value) error {
+		if omitEmpty && fv.IsZero() || isNilPointer(fv) {
+			return nil
+		}
+		fv = reflect.Indirect(fv)
+		if fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array {
+			req.Header.Del(name)
+			for i := range fv.Len() {
+				req.Header.Add(name, formatValue(fv.Index(i)))
+			}
+			return nil
+		}
+		req.Header.Set(name, formatValue(fv))
+		return nil
+	})
+}
+
+// NewRequestFromParams returns a [Client.NewRequest] for method and the path
+// registered with pathName, its path params, query params and headers bound
+// from the `path`, `query` and `header` tagged fields of params, ready to
+// [resty.Request.Send]. A {name} placeholder of the path bound neither by
+// params nor by the client path params returns [ErrMissingPathParam].
+//
+//	type GetPostParams struct {
+//		UserID string `path:"id"`
+//		Fields string `query:"fields,omitempty"`
+//		Tenant string `header:"X-Tenant-Id"`
+//	}
+//
+//	req, err := client.NewRequestFromParams(ctx, http.MethodGet, "getPost", &params)
+//	if err != nil {
+//		return nil, err
+//	}
+//	res, err := req.SetResult(&post).Send()
+func (c *Client) NewRequestFromParams(ctx context.Context, method, pathName string, params any) (*resty.Request, error) {
+	req := c.NewRequest(ctx).
+		SetMethod(method).
+		SetURL(c.GetPathContext(ctx, pathName))
+	for _, bind := range []func(*resty.Request, any) error{
+		bindPathParams,
+		SetQueryParamsFromStruct,
+		SetHeadersFromStruct,
+	} {
+		if err := bind(req, params); err != nil {
+			return nil, err
+		}
+	}
+	if name, ok := unboundPathParam(req.URL, c.PathParams(), req.PathParams); ok {
+		return nil, fmt.Errorf("%w: %s", ErrMissingPathParam, name)
+	}
+
+	return req, nil
+}
+
+// unboundPathParam returns the name of the first {name} placeholder of path
+// missing from all of params.
+func unboundPathParam(path string, params ...map[string]string) (string, bool) {
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			return "", false
+		}
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			return "", false
+		}
+		name := path[start+1 : start+end]
+		if !slices.ContainsFunc(params, func(p map[string]string) bool {
+			_, ok := p[name]
+			return ok
+		}) {
+			return name, true
+		}
+		path = path[start+end+1:]
+	}
+}
+
+// walkTaggedFields calls fn for every exported field of struct v with a
+// non-empty tag, the tag format is `tag:"name[,omitempty]"`.
+func walkTaggedFields(v any, tag string, fn func(name string, omitEmpty bool, fv reflect.Value) error) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ErrNotStruct
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if err := fn(name, opts == "omitempty", rv.Field(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isNilPointer reports whether fv is a nil pointer, a field without a value.
+func isNilPointer(fv reflect.Value) bool {
+	return fv.Kind() == reflect.Pointer && fv.IsNil()
+}
+
+// formatValue formats fv as a param value, dereferencing pointers.
+func formatValue(fv reflect.Value) string {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return ""
+		}
+		fv = fv.Elem()
+	}
+	return fmt.Sprint(fv.Interface())
+}
@@ -0,0 +1,226 @@
+package httpz
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetQueryParamsFromStruct(t *testing.T) {
+	type listUsersParams struct {
+		Status   []string `query:"status"`
+		Page     int      `query:"page,omitempty"`
+		Size     int      `query:"size"`
+		Sort     *string  `query:"sort,omitempty"`
+		Verbose  bool     `query:"verbose"`
+		Internal string   `query:"-"`
+		Untagged string
+	}
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/users",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+
+			assert.Equal(t, []string{"active", "pending"}, q["status"])
+			assert.False(t, q.Has("page"))
+			assert.Equal(t, "0", q.Get("size"))
+			assert.Equal(t, "name", q.Get("sort"))
+			assert.Equal(t, "true", q.Get("verbose"))
+			assert.False(t, q.Has("Internal"))
+			assert.False(t, q.Has("Untagged"))
+
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	client := NewClient("test-client", server.URL, WithPaths(map[string]string{
+		"listUsers": "/test/users",
+	}))
+	sort := "name"
+	req := client.NewRequest(context.Background())
+
+	err := SetQueryParamsFromStruct(req, &listUsersParams{
+		Status:   []string{"active", "pending"},
+		Sort:     &sort,
+		Verbose:  true,
+		Internal: "secret",
+		Untagged: "ignored",
+	})
+
+	require.NoError(t, err)
+
+	res, err := req.Get(client.GetPath("listUsers"))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+
+	t.Run("nil pointer fields", func(t *testing.T) {
+		req := client.NewRequest(context.Background())
+
+		err := SetQueryParamsFromStruct(req, &struct {
+			Cursor *string `query:"cursor"`
+			Limit  *int    `query:"limit"`
+		}{})
+
+		require.NoError(t, err)
+		assert.Empty(t, req.QueryParams)
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		err := SetQueryParamsFromStruct(client.NewRequest(context.Background()), map[string]string{})
+
+		assert.ErrorIs(t, err, ErrNotStruct)
+	})
+}
+
+func TestSetPathParamsFromStruct(t *testing.T) {
+	type getPostParams struct {
+		UserID string `path:"id"`
+		PostID int    `path:"postId"`
+	}
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/users/{id}/posts/{postId}",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "user-1", r.PathValue("id"))
+			assert.Equal(t, "42", r.PathValue("postId"))
+
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	client := NewClient("test-client", server.URL, WithPaths(map[string]string{
+		"getPost": "/test/users/{id}/posts/{postId}",
+	}))
+	req := client.NewRequest(context.Background())
+
+	err := SetPathParamsFromStruct(req, getPostParams{UserID: "user-1", PostID: 42})
+
+	require.NoError(t, err)
+
+	res, err := req.Get(client.GetPath("getPost"))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+
+	t.Run("zero field is bound", func(t *testing.T) {
+		req := client.NewRequest(context.Background())
+
+		err := SetPathParamsFromStruct(req, getPostParams{UserID: "user-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"id": "user-1", "postId": "0"}, req.PathParams)
+	})
+
+	t.Run("omitempty and nil pointer fields are skipped", func(t *testing.T) {
+		type params struct {
+			UserID string `path:"id,omitempty"`
+			PostID *int   `path:"postId"`
+		}
+		req := client.NewRequest(context.Background())
+
+		err := SetPathParamsFromStruct(req, params{})
+
+		require.NoError(t, err)
+		assert.Empty(t, req.PathParams)
+	})
+
+	t.Run("missing placeholder of the url", func(t *testing.T) {
+		type params struct {
+			UserID string `path:"id"`
+		}
+		req := client.NewRequest(context.Background()).SetURL(client.GetPath("getPost"))
+
+		err := SetPathParamsFromStruct(req, params{UserID: "user-1"})
+
+		assert.ErrorIs(t, err, ErrMissingPathParam)
+		assert.ErrorContains(t, err, "postId")
+	})
+
+	t.Run("placeholder bound by the request", func(t *testing.T) {
+		type params struct {
+			UserID string `path:"id"`
+		}
+		req := client.NewRequest(context.Background()).
+			SetURL(client.GetPath("getPost")).
+			SetPathParam("postId", "42")
+
+		err := SetPathParamsFromStruct(req, params{UserID: "user-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"id": "user-1", "postId": "42"}, req.PathParams)
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		err := SetPathParamsFromStruct(client.NewRequest(context.Background()), "user-1")
+
+		assert.ErrorIs(t, err, ErrNotStruct)
+	})
+}
+
+func TestNewRequestFromParams(t *testing.T) {
+	type listPostsParams struct {
+		UserID string   `path:"id"`
+		Status []string `query:"status"`
+		Page   int      `query:"page,omitempty"`
+		Tenant string   `header:"X-Tenant-Id"`
+		Locale string   `header:"Accept-Language,omitempty"`
+		Trace  *string  `header:"X-Debug-Trace"`
+		Retry  *int     `header:"X-Retry-Of"`
+	}
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/users/{id}/posts",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "user-1", r.PathValue("id"))
+			assert.Equal(t, url.Values{"status": {"draft", "published"}}, r.URL.Query())
+			assert.Equal(t, "tenant-1", r.Header.Get("X-Tenant-Id"))
+			assert.Empty(t, r.Header.Values("Accept-Language"))
+			assert.Empty(t, r.Header.Values("X-Debug-Trace"), "nil pointer field")
+			assert.Empty(t, r.Header.Values("X-Retry-Of"), "nil pointer field")
+
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	client := NewClient("test-client", server.URL, WithPaths(map[string]string{
+		"listPosts": "/test/users/{id}/posts",
+	}))
+
+	req, err := client.NewRequestFromParams(context.Background(), http.MethodGet, "listPosts", &listPostsParams{
+		UserID: "user-1",
+		Status: []string{"draft", "published"},
+		Tenant: "tenant-1",
+	})
+
+	require.NoError(t, err)
+
+	res, err := req.Send()
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+
+	t.Run("missing path param", func(t *testing.T) {
+		_, err := client.NewRequestFromParams(context.Background(), http.MethodGet, "listPosts", &struct {
+			UserID string `path:"id,omitempty"`
+		}{})
+
+		assert.ErrorIs(t, err, ErrMissingPathParam)
+		assert.ErrorContains(t, err, "id")
+	})
+
+	t.Run("path param bound by the client", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(map[string]string{
+			"listPosts": "/test/users/{id}/posts",
+		}))
+		client.SetPathParam("id", "user-1")
+
+		_, err := client.NewRequestFromParams(context.Background(), http.MethodGet, "listPosts", &struct {
+			Tenant string `header:"X-Tenant-Id"`
+		}{})
+
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,359 @@
+package httpz
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCacheMaxEntries is the number of responses kept by the cache, the
+	// least recently used one is evicted past it.
+	defaultCacheMaxEntries = 1000
+	// defaultCacheMaxBodySize is the largest body cached when
+	// [WithMaxResponseBodySize] isn't set.
+	defaultCacheMaxBodySize = 1 << 20
+)
+
+// responseCache is an in-memory LRU cache of GET responses honoring the
+// "Cache-Control" max-age and revalidating stale entries with their "ETag".
+type responseCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key      string
+	status   int
+	header   http.Header
+	body     []byte
+	etag     string
+	vary     map[string]string
+	maxAge   time.Duration
+	storedAt time.Time
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{
+		maxEntries: defaultCacheMaxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (c *responseCache) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*cacheEntry)
+}
+
+func (c *responseCache) set(key string, e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.key = key
+	if el, ok := c.entries[key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(e)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *responseCache) delete(match func(key string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, el := range c.entries {
+		if match(key) {
+			c.lru.Remove(el)
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (e *cacheEntry) fresh() bool {
+	return time.Since(e.storedAt) < e.maxAge
+}
+
+// matches reports whether req has the values of the request headers the entry
+// varies on.
+func (e *cacheEntry) matches(req *http.Request) bool {
+	for name, val := range e.vary {
+		if req.Header.Get(name) != val {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(e.status) + " " + http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// newCacheEntry returns the entry of a cacheable res to req, its body is read
+// and replaced so it can still be read by the caller. A body larger than
+// maxBodySize isn't cached.
+func newCacheEntry(req *http.Request, res *http.Response, maxBodySize int64) (*cacheEntry, error) {
+	if res.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+	maxAge, cacheable := parseCacheControl(res.Header.Get("Cache-Control"))
+	etag := res.Header.Get("ETag")
+	if !cacheable || (maxAge == 0 && etag == "") {
+		return nil, nil
+	}
+	vary, ok := varyValues(req, res)
+	if !ok {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize+1))
+	if err == nil && int64(len(body)) > maxBodySize {
+		// the caller still reads the whole body
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		return nil, nil
+	}
+	_ = res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return &cacheEntry{
+		status:   res.StatusCode,
+		header:   res.Header.Clone(),
+		body:     body,
+		etag:     etag,
+		vary:     vary,
+		maxAge:   maxAge,
+		storedAt: time.Now(),
+	}, nil
+}
+
+// varyValues returns the values of the request headers named by the "Vary"
+// header of res, and false when res varies on anything ("*").
+func varyValues(req *http.Request, res *http.Response) (map[string]string, bool) {
+	var vary map[string]string
+	for _, header := range res.Header.Values("Vary") {
+		for _, name := range strings.Split(header, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			switch name {
+			case "":
+				continue
+			case "*":
+				return nil, false
+			}
+			if vary == nil {
+				vary = make(map[string]string)
+			}
+			vary[name] = req.Header.Get(name)
+		}
+	}
+	return vary, true
+}
+
+// parseCacheControl returns the max-age of a "Cache-Control" header, and false
+// when the response must not be stored.
+func parseCacheControl(cc string) (time.Duration, bool) {
+	var maxAge time.Duration
+	for _, directive := range strings.Split(cc, ",") {
+		name, val, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "private":
+			// a private response is for a single user
+			return 0, false
+		case "no-cache":
+			// stored but always revalidated
+			return 0, true
+		case "max-age":
+			if secs, err := strconv.Atoi(strings.Trim(val, `"`)); err == nil && secs > 0 {
+				maxAge = time.Duration(secs) * time.Second
+			}
+		}
+	}
+	return maxAge, true
+}
+
+// cacheTransport serves GET requests from the cache while fresh, and
+// revalidates stale entries having an "ETag" with "If-None-Match".
+type cacheTransport struct {
+	next        http.RoundTripper
+	cache       *responseCache
+	maxBodySize int64
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// conditional and range requests are the caller's business, and the
+	// response to an authenticated request may be for its user only
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" ||
+		req.Header.Get("Range") != "" || req.Header.Get("Authorization") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	entry := t.cache.get(key)
+	if entry != nil && !entry.matches(req) {
+		entry = nil
+	}
+	if entry != nil && entry.fresh() {
+		return entry.response(req), nil
+	}
+
+	outReq := req
+	if entry != nil && entry.etag != "" {
+		outReq = req.Clone(req.Context())
+		outReq.Header.Set("If-None-Match", entry.etag)
+	}
+
+	res, err := t.next.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry != nil && res.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+		maxAge, _ := parseCacheControl(res.Header.Get("Cache-Control"))
+		t.cache.set(key, &cacheEntry{
+			status:   entry.status,
+			header:   entry.header,
+			body:     entry.body,
+			etag:     entry.etag,
+			vary:     entry.vary,
+			maxAge:   maxAge,
+			storedAt: time.Now(),
+		})
+		return entry.response(req), nil
+	}
+
+	newEntry, err := newCacheEntry(req, res, t.maxBodySize)
+	if err != nil {
+		return nil, err
+	}
+	if newEntry != nil {
+		t.cache.set(key, newEntry)
+	}
+
+	return res, nil
+}
+
+// InvalidateCache purges the cached responses of urlOrPathName, a path name
+// given to [WithPaths], a path relative to the base URL or an absolute URL,
+// with any query string, e.g. after a PUT or DELETE changed the resource of a
+// cached GET. A relative path is matched against every base URL, including the
+// ones of [WithBaseURLs], and a {name} segment of it, e.g. of the path
+// template "/users/{id}", matches any value. It's a no-op when
+// [WithResponseCacheEnabled] isn't set.
+func (c *Client) InvalidateCache(urlOrPathName string) {
+	if c.cfg.cache == nil {
+		return
+	}
+	c.cfg.cache.delete(c.cacheKeyMatcher(urlOrPathName, false))
+}
+
+// InvalidateCachePrefix purges the cached responses whose URL starts with
+// prefix, resolved like [Client.InvalidateCache], e.g. "/users/" purges every
+// cached user.
+func (c *Client) InvalidateCachePrefix(prefix string) {
+	if c.cfg.cache == nil {
+		return
+	}
+	c.cfg.cache.delete(c.cacheKeyMatcher(prefix, true))
+}
+
+// cacheKeyMatcher returns the matcher of the cache keys, the request URLs, of
+// urlOrPathName, their query string ignored, or starting with it when prefix
+// is set.
+func (c *Client) cacheKeyMatcher(urlOrPathName string, prefix bool) func(key string) bool {
+	if strings.HasPrefix(urlOrPathName, "http://") || strings.HasPrefix(urlOrPathName, "https://") {
+		return func(key string) bool {
+			if prefix {
+				return strings.HasPrefix(key, urlOrPathName)
+			}
+			base, _, _ := strings.Cut(key, "?")
+			return base == urlOrPathName
+		}
+	}
+
+	path := urlOrPathName
+	if p := c.GetPath(urlOrPathName); p != "" {
+		path = p
+	}
+	segments := strings.Split("/"+strings.TrimLeft(path, "/"), "/")
+	baseURLs := append([]string{c.BaseURL()}, c.cfg.baseURLs...)
+
+	return func(key string) bool {
+		key, _, _ = strings.Cut(key, "?")
+		for _, baseURL := range baseURLs {
+			baseURL = strings.TrimRight(baseURL, "/")
+			if baseURL == "" {
+				continue
+			}
+			rest, ok := strings.CutPrefix(key, baseURL)
+			if ok && strings.HasPrefix(rest, "/") && matchPathSegments(segments, strings.Split(rest, "/"), prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchPathSegments reports whether the escaped segments of a URL path match
+// the segments of a path template, a {name} segment matching any value. With
+// prefix the path may have more segments and its last matched segment only has
+// to start with the template one.
+func matchPathSegments(template, segments []string, prefix bool) bool {
+	if len(segments) < len(template) || !prefix && len(segments) != len(template) {
+		return false
+	}
+	for i, t := range template {
+		s, err := url.PathUnescape(segments[i])
+		if err != nil {
+			return false
+		}
+		switch {
+		case len(t) > 2 && strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}"):
+			if s == "" {
+				return false
+			}
+		case prefix && i == len(template)-1:
+			if !strings.HasPrefix(s, t) {
+				return false
+			}
+		case s != t:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,259 @@
+package httpz
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"resty.dev/v3"
+)
+
+func TestResponseCache(t *testing.T) {
+	hits := map[string]int{}
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/users/{id}",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				hits[r.URL.Path]++
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Cache-Control", "max-age=60")
+				_, _ = w.Write([]byte(`{"id":"` + r.PathValue("id") + `"}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/etag",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				hits[r.URL.Path]++
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set("Cache-Control", "no-cache")
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				_, _ = w.Write([]byte(`{"v":1}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/private",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				hits[r.URL.Path]++
+				w.Header().Set("Cache-Control", "private, max-age=60")
+				_, _ = w.Write([]byte(`{}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/vary",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				hits[r.URL.Path]++
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Header().Set("Vary", "Accept-Language")
+				_, _ = w.Write([]byte(`{"lang":"` + r.Header.Get("Accept-Language") + `"}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/large",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				hits[r.URL.Path]++
+				w.Header().Set("Cache-Control", "max-age=60")
+				_, _ = w.Write([]byte(`{"data":"` + strings.Repeat("x", 64) + `"}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/no-store",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				hits[r.URL.Path]++
+				w.Header().Set("Cache-Control", "no-store, max-age=60")
+				_, _ = w.Write([]byte(`{}`))
+			},
+		},
+	)
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"getFirstUser": "/users/first", "getUser": "/users/{id}"}),
+		WithResponseCacheEnabled(true),
+		WithMaxResponseBodySize(64),
+	)
+	get := func(t *testing.T, path string, headers ...string) string {
+		t.Helper()
+		req := client.NewRequest(context.Background())
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.SetHeader(headers[i], headers[i+1])
+		}
+		res, err := req.Get(path)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode())
+		return res.String()
+	}
+
+	t.Run("serves fresh response from cache", func(t *testing.T) {
+		clear(hits)
+
+		assert.Equal(t, `{"id":"1"}`, get(t, "/users/1"))
+		assert.Equal(t, `{"id":"1"}`, get(t, "/users/1"))
+		assert.Equal(t, 1, hits["/users/1"])
+	})
+
+	t.Run("invalidate hits server again", func(t *testing.T) {
+		clear(hits)
+		get(t, "/users/2")
+
+		client.InvalidateCache("/users/2")
+		get(t, "/users/2")
+
+		assert.Equal(t, 2, hits["/users/2"])
+	})
+
+	t.Run("invalidate by path name", func(t *testing.T) {
+		clear(hits)
+		get(t, client.GetPath("getFirstUser"))
+
+		client.InvalidateCache("getFirstUser")
+		get(t, client.GetPath("getFirstUser"))
+
+		assert.Equal(t, 2, hits["/users/first"])
+	})
+
+	t.Run("invalidate by path name with params", func(t *testing.T) {
+		clear(hits)
+		get(t, "/users/5")
+		get(t, "/users/6")
+
+		client.InvalidateCache("getUser")
+		get(t, "/users/5")
+		get(t, "/users/6")
+
+		assert.Equal(t, 2, hits["/users/5"])
+		assert.Equal(t, 2, hits["/users/6"])
+	})
+
+	t.Run("invalidate escaped path", func(t *testing.T) {
+		clear(hits)
+		get(t, "/users/a b")
+
+		client.InvalidateCache("/users/a b")
+		get(t, "/users/a b")
+
+		assert.Equal(t, 2, hits["/users/a b"])
+	})
+
+	t.Run("invalidate every base url", func(t *testing.T) {
+		u, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		baseURLs := []string{server.URL, "http://localhost:" + u.Port()}
+		client := NewClient("test-client", "",
+			WithBaseURLs(baseURLs),
+			WithResponseCacheEnabled(true),
+		)
+		clear(hits)
+		for range baseURLs {
+			_, err := client.NewRequest(context.Background()).Get("/users/7")
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, hits["/users/7"])
+
+		client.InvalidateCache("/users/7")
+		for range baseURLs {
+			_, err := client.NewRequest(context.Background()).Get("/users/7")
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 4, hits["/users/7"])
+	})
+
+	t.Run("invalidate by prefix", func(t *testing.T) {
+		clear(hits)
+		get(t, "/users/3")
+		get(t, "/users/4")
+
+		client.InvalidateCachePrefix("/users/")
+		get(t, "/users/3")
+		get(t, "/users/4")
+
+		assert.Equal(t, 2, hits["/users/3"])
+		assert.Equal(t, 2, hits["/users/4"])
+	})
+
+	t.Run("revalidates etag", func(t *testing.T) {
+		clear(hits)
+
+		assert.Equal(t, `{"v":1}`, get(t, "/etag"))
+		assert.Equal(t, `{"v":1}`, get(t, "/etag"))
+		assert.Equal(t, 2, hits["/etag"])
+	})
+
+	t.Run("no-store is not cached", func(t *testing.T) {
+		clear(hits)
+		get(t, "/no-store")
+		get(t, "/no-store")
+
+		assert.Equal(t, 2, hits["/no-store"])
+	})
+
+	t.Run("private is not cached", func(t *testing.T) {
+		clear(hits)
+		get(t, "/private")
+		get(t, "/private")
+
+		assert.Equal(t, 2, hits["/private"])
+	})
+
+	t.Run("authenticated request bypasses cache", func(t *testing.T) {
+		clear(hits)
+		get(t, "/users/5")
+
+		assert.Equal(t, `{"id":"5"}`, get(t, "/users/5", "Authorization", "Bearer token-a"))
+		assert.Equal(t, `{"id":"5"}`, get(t, "/users/5", "Authorization", "Bearer token-b"))
+		assert.Equal(t, 3, hits["/users/5"])
+	})
+
+	t.Run("serves only matching vary headers", func(t *testing.T) {
+		clear(hits)
+
+		assert.Equal(t, `{"lang":"en"}`, get(t, "/vary", "Accept-Language", "en"))
+		assert.Equal(t, `{"lang":"en"}`, get(t, "/vary", "Accept-Language", "en"))
+		assert.Equal(t, `{"lang":"fr"}`, get(t, "/vary", "Accept-Language", "fr"))
+		assert.Equal(t, 2, hits["/vary"])
+	})
+
+	t.Run("body over max size is not cached", func(t *testing.T) {
+		clear(hits)
+
+		for range 2 {
+			_, err := client.NewRequest(context.Background()).Get("/large")
+			require.ErrorIs(t, err, resty.ErrReadExceedsThresholdLimit)
+		}
+		assert.Equal(t, 2, hits["/large"])
+	})
+}
+
+func TestInvalidateCacheDisabled(t *testing.T) {
+	client := NewClient("test-client", "http://localhost")
+
+	assert.NotPanics(t, func() {
+		client.InvalidateCache("/users")
+		client.InvalidateCachePrefix("/users")
+	})
+}
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newResponseCache()
+	cache.maxEntries = 2
+
+	cache.set("a", &cacheEntry{})
+	cache.set("b", &cacheEntry{})
+	cache.get("a")
+	cache.set("c", &cacheEntry{})
+
+	assert.NotNil(t, cache.get("a"))
+	assert.Nil(t, cache.get("b"))
+	assert.NotNil(t, cache.get("c"))
+}
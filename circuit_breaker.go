@@ -0,0 +1,93 @@
+package httpz
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"resty.dev/v3"
+)
+
+// ErrCircuitBreakerWarmup is returned from the verb call when a request is
+// throttled while the circuit breaker warms up, see [WithCircuitBreakerWarmup].
+var ErrCircuitBreakerWarmup = errors.New("httpz: circuit breaker warming up")
+
+// PolicyServerErrors is a [WithCircuitBreaker] policy counting 5xx responses as
+// failures.
+func PolicyServerErrors(res *http.Response) bool {
+	return res.StatusCode >= http.StatusInternalServerError
+}
+
+// PolicyServerAndRateLimit is a [WithCircuitBreaker] policy counting 5xx and
+// 429 Too Many Requests responses as failures, the default policy.
+func PolicyServerAndRateLimit(res *http.Response) bool {
+	return PolicyServerErrors(res) || res.StatusCode == http.StatusTooManyRequests
+}
+
+// PolicyAllNon2xx is a [WithCircuitBreaker] policy counting every non-2xx
+// response as a failure.
+func PolicyAllNon2xx(res *http.Response) bool {
+	return res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices
+}
+
+// cbWarmup ramps up the admitted traffic linearly over duration once the
+// circuit breaker recovers, so a burst of queued requests can't immediately
+// trip it again.
+//
+// The resty circuit breaker state isn't observable, a recovery is detected as
+// the first request admitted by the breaker after it rejected one.
+type cbWarmup struct {
+	mu       sync.Mutex
+	duration time.Duration
+	tripped  bool
+	start    time.Time
+}
+
+func (w *cbWarmup) trip() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tripped = true
+	w.start = time.Time{}
+}
+
+func (w *cbWarmup) allow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.tripped {
+		// the half-open probe, always admitted so the breaker can close
+		w.tripped = false
+		w.start = time.Now()
+		return true
+	}
+	if w.start.IsZero() {
+		return true
+	}
+
+	elapsed := time.Since(w.start)
+	if elapsed >= w.duration {
+		w.start = time.Time{}
+		return true
+	}
+
+	return rand.Float64() < float64(elapsed)/float64(w.duration)
+}
+
+func throttleWarmup(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, _ *resty.Request) error {
+		if cfg.cbWarmup == nil || cfg.cbWarmup.allow() {
+			return nil
+		}
+		return ErrCircuitBreakerWarmup
+	}
+}
+
+func tripWarmup(cfg *config) resty.ErrorHook {
+	return func(_ *resty.Request, err error) {
+		if cfg.cbWarmup != nil && errors.Is(err, resty.ErrCircuitBreakerOpen) {
+			cfg.cbWarmup.trip()
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"resty.dev/v3"
+)
+
+func TestCircuitBreakerWarmup(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/200",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/500",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		},
+	)
+	cbTimeout := 50 * time.Millisecond
+	client := NewClient("test-circuit-breaker", server.URL,
+		WithPaths(map[string]string{
+			"success": "/200",
+			"fail":    "/500",
+		}),
+		WithCircuitBreaker(cbTimeout, 1, 1),
+		WithCircuitBreakerEnabled(true),
+		WithCircuitBreakerWarmup(time.Minute),
+	)
+
+	_, err := client.NewRequest(context.Background()).Get(client.GetPath("fail"))
+	require.NoError(t, err)
+	_, err = client.NewRequest(context.Background()).Get(client.GetPath("success"))
+	require.ErrorIs(t, err, resty.ErrCircuitBreakerOpen)
+
+	time.Sleep(cbTimeout + 50*time.Millisecond)
+
+	res, err := client.NewRequest(context.Background()).Get(client.GetPath("success"))
+	require.NoError(t, err, "half-open probe must be admitted")
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+
+	admitted, throttled := 0, 0
+	for range 50 {
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("success"))
+		switch {
+		case err == nil:
+			admitted++
+		case errors.Is(err, ErrCircuitBreakerWarmup):
+			throttled++
+		default:
+			require.NoError(t, err)
+		}
+	}
+	assert.Less(t, admitted, 5)
+	assert.Equal(t, 50, admitted+throttled)
+}
+
+func TestCircuitBreakerDefaultPolicy(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/400",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/429",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+		},
+	)
+	paths := map[string]string{
+		"badRequest":      "/400",
+		"tooManyRequests": "/429",
+	}
+	failureThreshold := uint32(3)
+
+	t.Run("400 doesn't open the breaker", func(t *testing.T) {
+		client := NewClient("test-circuit-breaker", server.URL,
+			WithPaths(paths),
+			WithCircuitBreaker(time.Minute, failureThreshold, 1),
+			WithCircuitBreakerEnabled(true),
+		)
+
+		for range failureThreshold + 2 {
+			res, err := client.NewRequest(context.Background()).Get(client.GetPath("badRequest"))
+
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode())
+		}
+	})
+
+	t.Run("429 opens the breaker", func(t *testing.T) {
+		client := NewClient("test-circuit-breaker", server.URL,
+			WithPaths(paths),
+			WithCircuitBreaker(time.Minute, failureThreshold, 1),
+			WithCircuitBreakerEnabled(true),
+		)
+
+		for range failureThreshold {
+			_, err := client.NewRequest(context.Background()).Get(client.GetPath("tooManyRequests"))
+
+			require.NoError(t, err)
+		}
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("tooManyRequests"))
+
+		assert.ErrorIs(t, err, resty.ErrCircuitBreakerOpen)
+	})
+}
+
+func TestCircuitBreakerPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy func(*http.Response) bool
+		want   map[int]bool
+	}{
+		{
+			name:   "server errors",
+			policy: PolicyServerErrors,
+			want:   map[int]bool{200: false, 204: false, 304: false, 404: false, 429: false, 500: true, 503: true},
+		},
+		{
+			name:   "server errors and rate limit",
+			policy: PolicyServerAndRateLimit,
+			want:   map[int]bool{200: false, 204: false, 304: false, 404: false, 429: true, 500: true, 503: true},
+		},
+		{
+			name:   "all non 2xx",
+			policy: PolicyAllNon2xx,
+			want:   map[int]bool{101: true, 200: false, 204: false, 304: true, 404: true, 429: true, 500: true, 503: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for code, want := range tt.want {
+				assert.Equal(t, want, tt.policy(&http.Response{StatusCode: code}), "status code %d", code)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"resty.dev/v3"
+)
+
+// ErrorClass tells whether a failed request is worth retrying, see [Classify].
+type ErrorClass int
+
+const (
+	// NoError is the class of a request that didn't fail.
+	NoError ErrorClass = iota
+	// Transient is the class of a failure that may succeed when retried,
+	// e.g. a network error, a timeout, a 5xx, 408 or 429 response.
+	Transient
+	// Permanent is the class of a failure that will fail again, e.g. a 4xx
+	// response, a canceled context or a decode error.
+	Permanent
+	// CircuitOpen is the class of a request denied by the circuit breaker,
+	// it may be retried once the circuit breaker closes.
+	CircuitOpen
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case NoError:
+		return "no_error"
+	case Transient:
+		return "transient"
+	case Permanent:
+		return "permanent"
+	case CircuitOpen:
+		return "circuit_open"
+	default:
+		return "unknown"
+	}
+}
+
+// Classify classifies the outcome of a request from the error and the response
+// returned by the verb call, so callers can decide whether to retry at a higher
+// level.
+//
+//	res, err := client.NewRequest(ctx).Get(path)
+//	if httpz.Classify(err, res) == httpz.Transient {
+//		// retry later
+//	}
+func Classify(err error, res *resty.Response) ErrorClass {
+	if err != nil {
+		return classifyError(err)
+	}
+	if res == nil {
+		return NoError
+	}
+
+	switch code := res.StatusCode(); {
+	case code >= http.StatusInternalServerError,
+		code == http.StatusRequestTimeout,
+		code == http.StatusTooManyRequests:
+		return Transient
+	case code >= http.StatusBadRequest:
+		return Permanent
+	default:
+		return NoError
+	}
+}
+
+func classifyError(err error) ErrorClass {
+	switch {
+	case errors.Is(err, resty.ErrCircuitBreakerOpen),
+		errors.Is(err, ErrCircuitBreakerWarmup):
+		return CircuitOpen
+	case errors.Is(err, context.Canceled):
+		return Permanent
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrResponseDecodeTimeout):
+		return Transient
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return Transient
+	}
+
+	return Permanent
+}
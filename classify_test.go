@@ -0,0 +1,62 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"resty.dev/v3"
+)
+
+func TestClassify(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/status/{code}",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			var code int
+			_, _ = fmt.Sscan(r.PathValue("code"), &code)
+			w.WriteHeader(code)
+		},
+	})
+	client := NewClient("test-client", server.URL)
+	get := func(code int) *resty.Response {
+		res, err := client.NewRequest(context.Background()).Get(fmt.Sprintf("/test/status/%d", code))
+		assert.NoError(t, err)
+		return res
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		res  *resty.Response
+		want ErrorClass
+	}{
+		{name: "2xx", res: get(http.StatusOK), want: NoError},
+		{name: "4xx", res: get(http.StatusNotFound), want: Permanent},
+		{name: "408", res: get(http.StatusRequestTimeout), want: Transient},
+		{name: "429", res: get(http.StatusTooManyRequests), want: Transient},
+		{name: "5xx", res: get(http.StatusBadGateway), want: Transient},
+		{name: "circuit breaker open", err: resty.ErrCircuitBreakerOpen, want: CircuitOpen},
+		{name: "circuit breaker warmup", err: ErrCircuitBreakerWarmup, want: CircuitOpen},
+		{name: "context canceled", err: fmt.Errorf("get: %w", context.Canceled), want: Permanent},
+		{name: "context deadline exceeded", err: fmt.Errorf("get: %w", context.DeadlineExceeded), want: Transient},
+		{name: "other error", err: errors.New("boom"), want: Permanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Classify(tt.err, tt.res))
+		})
+	}
+
+	t.Run("network error", func(t *testing.T) {
+		client := NewClient("test-client", "http://127.0.0.1:1")
+
+		res, err := client.NewRequest(context.Background()).Get("/")
+
+		assert.Error(t, err)
+		assert.Equal(t, Transient, Classify(err, res))
+	})
+}
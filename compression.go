@@ -0,0 +1,88 @@
+package httpz
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// gzipMagic are the first bytes of a gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compressionSniffTransport decompresses the gzip response bodies of the
+// misconfigured servers omitting the "Content-Encoding" header, recognized by
+// their magic bytes, see [WithResponseBodyCompressionDetection]. Only JSON, text
+// and untyped bodies are sniffed, a gzip download is left untouched.
+type compressionSniffTransport struct {
+	next http.RoundTripper
+}
+
+func (t *compressionSniffTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil || res.Body == nil || res.Body == http.NoBody || res.Header.Get("Content-Encoding") != "" ||
+		!isSniffedContentType(res.Header.Get("Content-Type")) {
+		return res, err
+	}
+
+	res.Body = &sniffedBody{res: res, body: res.Body}
+
+	return res, nil
+}
+
+// isSniffedContentType reports whether a body of contentType, missing, JSON or
+// text, may be a gzip stream sent without its "Content-Encoding".
+func isSniffedContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json") ||
+		strings.HasPrefix(mediaType, "text/")
+}
+
+// sniffedBody sniffs the magic bytes of the body on the first Read rather than
+// in RoundTrip, not to hold back the headers of a streamed response, then reads
+// it through the gzip reader when they match. It closes the original body.
+type sniffedBody struct {
+	res    *http.Response
+	body   io.ReadCloser
+	reader io.Reader
+	err    error
+}
+
+func (b *sniffedBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.reader, b.err = b.sniff()
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+func (b *sniffedBody) sniff() (io.Reader, error) {
+	br := bufio.NewReader(b.body)
+	if magic, _ := br.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+		return br, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	b.res.Header.Del("Content-Length")
+	b.res.ContentLength = -1
+	b.res.Uncompressed = true
+
+	return zr, nil
+}
+
+func (b *sniffedBody) Close() error { return b.body.Close() }
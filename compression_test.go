@@ -0,0 +1,110 @@
+package httpz
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseBodyCompressionDetection(t *testing.T) {
+	gz := &bytes.Buffer{}
+	zw := gzip.NewWriter(gz)
+	_, err := zw.Write([]byte(`{"name":"alice"}`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	release := make(chan struct{})
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/compression/gzip",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(gz.Bytes())
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/compression/download",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/gzip")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(gz.Bytes())
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/compression/stream",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.WriteHeader(http.StatusOK)
+				w.(http.Flusher).Flush()
+				<-release
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/compression/plain",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"name":"bob"}`))
+			},
+		},
+	)
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithResponseBodyCompressionDetection(true))
+
+		for path, name := range map[string]string{
+			"/test/compression/gzip":  "alice",
+			"/test/compression/plain": "bob",
+		} {
+			res, err := client.NewRequest(context.Background()).SetResult(&user{}).Get(path)
+
+			require.NoError(t, err, path)
+			assert.Equal(t, &user{Name: name}, res.Result(), path)
+		}
+	})
+
+	t.Run("gzip download is kept as is", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithResponseBodyCompressionDetection(true))
+
+		res, err := client.NewRequest(context.Background()).Get("/test/compression/download")
+
+		require.NoError(t, err)
+		assert.Equal(t, gz.Bytes(), res.Bytes())
+	})
+
+	t.Run("stream headers are not held back", func(t *testing.T) {
+		defer close(release)
+		client := NewClient("test-client", server.URL, WithResponseBodyCompressionDetection(true))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		start := time.Now()
+
+		res, err := client.NewRequest(ctx).SetDoNotParseResponse(true).Get("/test/compression/stream")
+
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client := NewClient("test-client", server.URL)
+
+		_, err := client.NewRequest(context.Background()).SetResult(&user{}).Get("/test/compression/gzip")
+
+		assert.Error(t, err)
+	})
+}
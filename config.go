@@ -1,10 +1,17 @@
 package httpz
 
 import (
+	"context"
+	"crypto/tls"
 	"log/slog"
+	"maps"
 	"net/http"
+	"os"
+	"slices"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 	"resty.dev/v3"
@@ -12,22 +19,132 @@ import (
 
 type (
 	config struct {
+		httpClient            *http.Client
 		transport             http.RoundTripper
+		transportWrappers     []func(http.RoundTripper) http.RoundTripper
 		baseHeaders           map[string]string
+		pinnedHeaders         map[string]string
+		pathHeaders           map[string]map[string]string
+		headerFuncs           map[string]func() string
+		requiredResHeaders    []string
+		statusHandlers        map[int][]func(*resty.Response)
+		interceptors          []Interceptor
 		paths                 map[string]string
+		pathNames             map[string]string
+		resolvedPathNames     map[string]string
+		pathsMu               sync.RWMutex
+		pathTimeouts          map[string]time.Duration
+		methodTimeouts        map[string]time.Duration
+		pathResolver          func(ctx context.Context, name string) string
+		expectedResKeys       map[string][]string
+		baseURLs              []string
+		baseURLWeights        map[string]int
+		resBodyToCtx          bool
+		recordResTime         bool
+		lbStrategy            LoadBalancingStrategy
+		balancer              *balancer
+		cbTimeout             time.Duration
+		cbFailureThreshold    uint32
+		cbPolicies            []func(*http.Response) bool
 		logger                *slog.Logger
+		ctxLogger             func(ctx context.Context) *slog.Logger
 		tracer                trace.TracerProvider
 		propagator            propagation.TextMapPropagator
+		meter                 metric.MeterProvider
+		instruments           *instruments
+		latency               *latencyRecorder
+		metricsNamespace      string
+		durationBuckets       []float64
 		serviceVersion        string
+		peerService           string
 		circuitBreaker        *resty.CircuitBreaker
+		cbWarmup              *cbWarmup
+		redirectPolicies      []resty.RedirectPolicy
+		cache                 *responseCache
+		onResponseBytes       func(ctx context.Context, b []byte)
+		reqBodyLogFormatter   func(body any) any
+		reqBodyValidator      func(body any) error
+		autoContentLength     bool
+		cloneReqBodyForLog    bool
+		logBodyOnErrorOnly    bool
+		logBaggageKeys        []string
+		maxLogBodyDepth       int
+		reqLogSampleRate      *float64
+		resLogSampleRate      *float64
+		nonceHeader           string
+		requestIDHeader       string
+		schemaVersionHeader   string
+		schemaVersion         string
+		schemaVersionStrict   bool
+		asyncWorkers          int
+		sniffCompression      bool
+		resIdleTimeout        time.Duration
+		resTrailers           []string
+		maxResponseBodySize   int64
+		errorBodyMaxSize      int64
+		replayMaxSize         int64
+		decodeTimeout         time.Duration
+		resTransformer        func(raw []byte) ([]byte, error)
+		useNumber             bool
+		mapTimeoutErr         bool
+		basicAuth             *basicAuth
+		authToken             string
+		authScheme            string
+		reauth                func(ctx context.Context) error
+		forwardedForHeader    string
+		traceIDHeader         string
+		deadlineHeader        string
+		cipherSuites          []uint16
+		tlsMinVersion         uint16
+		expectContinueTimeout time.Duration
+		maxConnLifetime       time.Duration
+		maxIdleConnDuration   time.Duration
+		dialNetwork           string
+		dnsCacheTTL           time.Duration
+		dnsLookupHost         func(ctx context.Context, host string) ([]string, error)
+		tlsConfigHook         func(*tls.Config)
+		certReloader          func() (*tls.Certificate, error)
 		logMWEnabled          bool
 		otelMWEnabled         bool
 		circuitBreakerEnabled bool
+		metricsMWEnabled      bool
+		cbDenialMetrics       bool
+		startupProbe          *startupProbe
+		retryNonIdempotent    bool
+		maxRetryElapsed       time.Duration
+		retryResetReader      bool
+		retryTokens           *retryTokenBucket
+		captureRawResponse    bool
+		resNullFields         bool
+		ctDetectionEnabled    bool
+		strictContentType     bool
+		assumeJSON            bool
+		resContentTypes       map[string]string
+		span4xxNotError       bool
+		traceAttemptErrors    bool
+		serverTimingEnabled   bool
 	}
 )
 
+type basicAuth struct {
+	username string
+	password string
+}
+
 type option func(*config)
 
+// WithHTTPClient sends the requests with a copy of c, keeping its cookie jar,
+// timeout and redirect policy. It takes precedence over [WithTransport]: the
+// transport of c, or [http.DefaultTransport] when nil, is the one the transport
+// options and wrappers apply to.
+func WithHTTPClient(c *http.Client) option {
+	return option(func(cfg *config) {
+		if c != nil {
+			cfg.httpClient = c
+		}
+	})
+}
+
 func WithTransport(t *http.Transport) option {
 	return option(func(cfg *config) {
 		if t != nil {
@@ -36,6 +153,153 @@ func WithTransport(t *http.Transport) option {
 	})
 }
 
+// WithTransportWrapper wraps the transport, e.g. for header injection or a VCR,
+// keeping the transport set by [WithTransport] and the transport options.
+// Wrappers compose in order, the first one sees the request first.
+func WithTransportWrapper(wrap func(http.RoundTripper) http.RoundTripper) option {
+	return option(func(cfg *config) {
+		if wrap != nil {
+			cfg.transportWrappers = append(cfg.transportWrappers, wrap)
+		}
+	})
+}
+
+// WithExpectContinueTimeout sets the transport ExpectContinueTimeout, the time
+// to wait for the server's first response headers after sending the request
+// headers of a request with "Expect: 100-continue", so a large upload the server
+// rejects isn't sent in full.
+//
+// default: the transport default, 1s for [http.DefaultTransport]
+func WithExpectContinueTimeout(d time.Duration) option {
+	return option(func(cfg *config) {
+		cfg.expectContinueTimeout = d
+	})
+}
+
+// WithDNSCache caches the resolved addresses of a host for ttl, reducing the
+// DNS load and tail latency of high-QPS clients. A failed lookup, or cached
+// addresses that can't be dialed, fall back to a live lookup. The transport
+// must be *[http.Transport].
+//
+// default: 0 (disabled)
+func WithDNSCache(ttl time.Duration) option {
+	return option(func(cfg *config) {
+		cfg.dnsCacheTTL = ttl
+	})
+}
+
+// WithDialPreferIPv4 dials IPv4 addresses first in dual-stack environments,
+// e.g. to work around a broken IPv6 path, falling back to any family when it
+// fails. The transport must be *[http.Transport].
+func WithDialPreferIPv4(enabled bool) option {
+	return withDialNetwork("tcp4", enabled)
+}
+
+// WithDialPreferIPv6 dials IPv6 addresses first in dual-stack environments,
+// falling back to any family when it fails. The transport must be
+// *[http.Transport].
+func WithDialPreferIPv6(enabled bool) option {
+	return withDialNetwork("tcp6", enabled)
+}
+
+func withDialNetwork(network string, enabled bool) option {
+	return option(func(cfg *config) {
+		if enabled {
+			cfg.dialNetwork = network
+		} else if cfg.dialNetwork == network {
+			cfg.dialNetwork = ""
+		}
+	})
+}
+
+// WithMaxConnLifetime closes HTTP/1.1 connections older than d, once idle, so
+// new connections are dialed and spread over the backends again, e.g. after a
+// load balancer scaled out or a backend died.
+//
+// default: 0 (unlimited)
+func WithMaxConnLifetime(d time.Duration) option {
+	return option(func(cfg *config) {
+		cfg.maxConnLifetime = d
+	})
+}
+
+// WithMaxIdleConnDuration closes connections idle in the pool for longer than
+// d, with [WithMetricsMWEnabled] the connection reuse metric tells whether
+// keep-alive is effective.
+//
+// default: transport default (90s for [http.DefaultTransport])
+func WithMaxIdleConnDuration(d time.Duration) option {
+	return option(func(cfg *config) {
+		cfg.maxIdleConnDuration = d
+	})
+}
+
+// WithTLSMinVersion sets the minimum TLS version accepted by the transport,
+// e.g. [tls.VersionTLS12] for compliance.
+func WithTLSMinVersion(version uint16) option {
+	return option(func(cfg *config) {
+		cfg.tlsMinVersion = version
+	})
+}
+
+// WithCipherSuites restricts the TLS 1.0-1.2 cipher suites offered by the
+// transport, see [tls.CipherSuites]. TLS 1.3 cipher suites aren't configurable.
+func WithCipherSuites(suites []uint16) option {
+	return option(func(cfg *config) {
+		if len(suites) > 0 {
+			cfg.cipherSuites = suites
+		}
+	})
+}
+
+// WithTLSConfigHook calls hook with the TLS config of the transport before the
+// other TLS options are applied, e.g. to enable SPIFFE mutual TLS with
+// [github.com/unlimited-budget-ecommerce/httpz/spiffe.TLSConfigHook]. The
+// transport given to [WithTransport] isn't mutated, its TLS config is cloned.
+func WithTLSConfigHook(hook func(*tls.Config)) option {
+	return option(func(cfg *config) {
+		if hook != nil {
+			cfg.tlsConfigHook = hook
+		}
+	})
+}
+
+// WithClientCertReloader presents the client certificate returned by reload for
+// mutual TLS, called on every TLS handshake so a short-lived certificate
+// rotated on disk, e.g. by cert-manager, is picked up by the next connection.
+// The existing connections keep the certificate they were opened with.
+func WithClientCertReloader(reload func() (*tls.Certificate, error)) option {
+	return option(func(cfg *config) {
+		if reload != nil {
+			cfg.certReloader = reload
+		}
+	})
+}
+
+// WithMaxRedirects follows at most n redirects, the next redirect response is
+// returned as is instead of being followed, e.g. n = 0 returns a 302 so its
+// "Location" header can be read.
+//
+// default: 10 (the [http.Client] default)
+func WithMaxRedirects(n int) option {
+	return option(func(cfg *config) {
+		if n >= 0 {
+			cfg.redirectPolicies = append(cfg.redirectPolicies, maxRedirectsPolicy(n))
+		}
+	})
+}
+
+// WithRedirectPolicy adds a redirect policy, see [http.Client.CheckRedirect].
+// Policies are applied in the order they're added, combined with
+// [WithMaxRedirects].
+func WithRedirectPolicy(policy func(req *http.Request, via []*http.Request) error) option {
+	return option(func(cfg *config) {
+		if policy != nil {
+			cfg.redirectPolicies = append(cfg.redirectPolicies, resty.RedirectPolicyFunc(policy))
+		}
+	})
+}
+
 func WithBaseHeaders(h map[string]string) option {
 	return option(func(cfg *config) {
 		if h != nil {
@@ -44,10 +308,586 @@ func WithBaseHeaders(h map[string]string) option {
 	})
 }
 
+// WithPathHeaders sets default headers on the requests to a path, keyed by the
+// path name given to [WithPaths], e.g. a different "Accept" for one endpoint.
+// The request is matched on its path template, e.g. [Client.GetPath].
+//
+// Precedence, from lowest to highest: [WithBaseHeaders], path headers,
+// per-request headers (including the ones set by [Client.NewRequest]).
+func WithPathHeaders(h map[string]map[string]string) option {
+	return option(func(cfg *config) {
+		if h != nil {
+			cfg.pathHeaders = h
+		}
+	})
+}
+
+// WithPinnedHeaders sets headers on every request that can't be overridden,
+// e.g. a mandatory API key or security header.
+//
+// Precedence, from lowest to highest: [WithBaseHeaders], per-request headers
+// (e.g. [resty.Request.SetHeader]), pinned headers.
+func WithPinnedHeaders(h map[string]string) option {
+	return option(func(cfg *config) {
+		if h != nil {
+			cfg.pinnedHeaders = h
+		}
+	})
+}
+
+// WithClientInterceptorChain sets the ordered chain of named middlewares run
+// around every request, replacing [DefaultInterceptorChain], so the order of
+// the built-in log, trace and metrics interceptors and of custom ones is
+// controlled explicitly. Request middlewares run in chain order, response
+// middlewares in reverse order. A panic in the middlewares of a custom
+// interceptor is returned as an error wrapping [ErrMiddlewarePanic].
+//
+//	httpz.WithClientInterceptorChain(
+//		httpz.Interceptor{Name: httpz.InterceptorTrace},
+//		httpz.Interceptor{Name: "audit", Request: auditRequest},
+//		httpz.Interceptor{Name: httpz.InterceptorLog},
+//	)
+func WithClientInterceptorChain(chain ...Interceptor) option {
+	return option(func(cfg *config) {
+		cfg.interceptors = append([]Interceptor{}, chain...)
+	})
+}
+
+// WithOnStatus calls fn for every response with the status code, e.g. to read
+// "Retry-After" of a 429 into metrics. Handlers of the same code are called in
+// order.
+func WithOnStatus(code int, fn func(*resty.Response)) option {
+	return option(func(cfg *config) {
+		if fn == nil {
+			return
+		}
+		if cfg.statusHandlers == nil {
+			cfg.statusHandlers = make(map[int][]func(*resty.Response))
+		}
+		cfg.statusHandlers[code] = append(cfg.statusHandlers[code], fn)
+	})
+}
+
+// WithRequiredResponseHeaders fails the request with
+// [ErrMissingResponseHeader] when a response omits one of the given headers,
+// e.g. "X-Api-Version", catching a misconfigured gateway early.
+func WithRequiredResponseHeaders(headers []string) option {
+	return option(func(cfg *config) {
+		cfg.requiredResHeaders = headers
+	})
+}
+
+// WithAsyncWorkers sets the number of requests sent by [Client.Go] in flight at
+// once, a further one waits for a slot.
+//
+// default: 10
+func WithAsyncWorkers(n int) option {
+	return option(func(cfg *config) {
+		cfg.asyncWorkers = n
+	})
+}
+
+// WithResponseTrailers logs the given response trailers, e.g. "Grpc-Status",
+// with the response and sets them as "http.response.trailer.<name>" span
+// attributes. Every trailer can be read with [Trailer] regardless.
+func WithResponseTrailers(names []string) option {
+	return option(func(cfg *config) {
+		cfg.resTrailers = names
+	})
+}
+
+// WithExpectedSchemaVersion logs a warning when the response header named
+// header isn't version, e.g. "X-Schema-Version" "2", catching a request routed
+// to an old backend. A response without the header is a mismatch too.
+func WithExpectedSchemaVersion(header, version string) option {
+	return option(func(cfg *config) {
+		cfg.schemaVersionHeader = header
+		cfg.schemaVersion = version
+	})
+}
+
+// WithSchemaVersionStrict fails the request with [ErrSchemaVersionMismatch]
+// instead of logging a warning on a [WithExpectedSchemaVersion] mismatch.
+func WithSchemaVersionStrict(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.schemaVersionStrict = enabled
+	})
+}
+
+// WithPerRequestHeaderFunc sets the header name to the value returned by fn,
+// evaluated on every attempt, e.g. a cache-busting value for a CDN. It
+// overrides the header set on the request.
+func WithPerRequestHeaderFunc(name string, fn func() string) option {
+	return option(func(cfg *config) {
+		if name == "" || fn == nil {
+			return
+		}
+		if cfg.headerFuncs == nil {
+			cfg.headerFuncs = make(map[string]func() string)
+		}
+		cfg.headerFuncs[name] = fn
+	})
+}
+
+// WithNonceHeader sets an anti-replay nonce header, required by some security
+// gateways, on every request.
+//
+// Unlike an idempotency key, which stays the same across retries so the server
+// can deduplicate them, the nonce is regenerated on every attempt so each one
+// is unique.
+func WithNonceHeader(name string) option {
+	return option(func(cfg *config) {
+		cfg.nonceHeader = name
+	})
+}
+
+// WithOutgoingRequestID sets a request ID, generated once per request, in the
+// request header named header, on the "httpz.request.id" span attribute and
+// log attribute of both log lines, correlating them with the server logs. It
+// stays the same across retries, see [RequestIDFromContext].
+//
+// default header: "X-Request-Id"
+func WithOutgoingRequestID(header string) option {
+	return option(func(cfg *config) {
+		if header == "" {
+			header = "X-Request-Id"
+		}
+		cfg.requestIDHeader = header
+	})
+}
+
+// WithForwardedForFromContext forwards the original client IP, stored in the
+// request context with [WithClientIP], to the server in the header named
+// header. It's useful when the service calling httpz sits behind an ingress.
+//
+// default header: "X-Forwarded-For"
+func WithForwardedForFromContext(header string) option {
+	return option(func(cfg *config) {
+		if header == "" {
+			header = "X-Forwarded-For"
+		}
+		cfg.forwardedForHeader = header
+	})
+}
+
+// WithTraceIDHeader sets the trace ID of the request span, see [TraceID], in
+// the request header named header, so the server can log it.
+//
+// default header: "X-Trace-Id"
+func WithTraceIDHeader(header string) option {
+	return option(func(cfg *config) {
+		if header == "" {
+			header = "X-Trace-Id"
+		}
+		cfg.traceIDHeader = header
+	})
+}
+
+// WithDeadlinePropagationHeader sets the remaining time of the request context
+// deadline in milliseconds in the request header named header, so the server
+// can align its own timeout. Requests without a deadline don't get the header.
+//
+// default header: "X-Request-Timeout-Ms"
+func WithDeadlinePropagationHeader(header string) option {
+	return option(func(cfg *config) {
+		if header == "" {
+			header = "X-Request-Timeout-Ms"
+		}
+		cfg.deadlineHeader = header
+	})
+}
+
+// WithBasicAuth sets basic auth credentials on every request, a per-request
+// [resty.Request.SetBasicAuth] overrides it.
+func WithBasicAuth(username, password string) option {
+	return option(func(cfg *config) {
+		cfg.basicAuth = &basicAuth{username: username, password: password}
+	})
+}
+
+// WithAuthToken sets the "Authorization" header token on every request, e.g.
+// a static service token. A per-request [resty.Request.SetAuthToken] overrides it.
+func WithAuthToken(token string) option {
+	return option(func(cfg *config) {
+		cfg.authToken = token
+	})
+}
+
+// WithAuthScheme sets the "Authorization" header scheme used with
+// [WithAuthToken].
+//
+// default: "Bearer"
+func WithAuthScheme(scheme string) option {
+	return option(func(cfg *config) {
+		cfg.authScheme = scheme
+	})
+}
+
+// WithReauthOn401 calls refresh and retries the request once when the server
+// responds with 401 Unauthorized, e.g. after a token expired.
+//
+// refresh is expected to update the client credentials with
+// [resty.Client.SetAuthToken], the retried request carries the client's
+// current token. The concurrent 401s share a single refresh call. A request
+// sent with its own token, or whose body can't be replayed, isn't retried.
+func WithReauthOn401(refresh func(ctx context.Context) error) option {
+	return option(func(cfg *config) {
+		if refresh != nil {
+			cfg.reauth = refresh
+		}
+	})
+}
+
+// WithPaths registers the path templates by name, see [Client.GetPath], p is
+// copied so [Client.AddPath] never modifies it.
 func WithPaths(p map[string]string) option {
 	return option(func(cfg *config) {
 		if p != nil {
-			cfg.paths = p
+			cfg.paths = maps.Clone(p)
+		}
+	})
+}
+
+// WithPathResolver resolves path names with resolve before the templates of
+// [WithPaths], e.g. to route an A/B experiment to an alternate endpoint from a
+// context value, see [Client.GetPathContext]. An empty result falls back to
+// the registered template.
+//
+// The per-path options, e.g. [WithPathHeaders], apply to a resolved template
+// only when it's registered too.
+func WithPathResolver(resolve func(ctx context.Context, name string) string) option {
+	return option(func(cfg *config) {
+		if resolve != nil {
+			cfg.pathResolver = resolve
+		}
+	})
+}
+
+// WithResponseBodyCompressionDetection decompresses a gzip response body sent
+// without the "Content-Encoding" header by a misconfigured server, recognized by
+// its magic bytes, instead of failing to decode it. Only the bodies with a JSON
+// or text "Content-Type", or none, are sniffed, so a gzip download, e.g. an
+// "application/gzip" or "application/octet-stream" file, is kept as is.
+func WithResponseBodyCompressionDetection(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.sniffCompression = enabled
+	})
+}
+
+// WithResponseBodyToContext stores the response body in the request context of
+// the returned response, the decoded result or error value, or the raw bytes
+// when there's none, read with [ResponseBodyFromContext] by the later
+// middlewares and hooks, e.g. to build audit trails.
+func WithResponseBodyToContext(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.resBodyToCtx = enabled
+	})
+}
+
+// WithResponseTimeRecording stores the request duration in the request context
+// of the returned response, read with [ResponseDuration], independently of the
+// metrics middleware.
+func WithResponseTimeRecording(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.recordResTime = enabled
+	})
+}
+
+// WithExpectedResponseKeys logs a warning when the top level keys of a
+// successful JSON object response to pathName aren't exactly keys, detecting
+// backend contract drifts early. It can be set for several path names.
+func WithExpectedResponseKeys(pathName string, keys []string) option {
+	return option(func(cfg *config) {
+		if cfg.expectedResKeys == nil {
+			cfg.expectedResKeys = make(map[string][]string)
+		}
+		cfg.expectedResKeys[pathName] = append([]string{}, keys...)
+	})
+}
+
+// WithBaseURLs spreads the requests over urls, taking precedence over the base
+// URL of [NewClient], with the [WithLoadBalancingStrategy] strategy. A retry
+// may go to another base URL.
+//
+// A base URL failing as many times in a row as the [WithCircuitBreaker]
+// failure threshold is ejected for its timeout, with the same defaults and
+// policies, whether the client-wide circuit breaker is enabled or not.
+func WithBaseURLs(urls []string) option {
+	return option(func(cfg *config) {
+		cfg.baseURLs, cfg.baseURLWeights = urls, nil
+	})
+}
+
+// WithWeightedBaseURLs is [WithBaseURLs] splitting the requests at random in
+// proportion to the weights, e.g. 90 and 10 for a canary getting 10% of the
+// traffic, instead of following [WithLoadBalancingStrategy]. A base URL
+// weighing 0 or less gets no traffic.
+func WithWeightedBaseURLs(weights map[string]int) option {
+	return option(func(cfg *config) {
+		cfg.baseURLs, cfg.baseURLWeights = nil, nil
+		for _, u := range slices.Sorted(maps.Keys(weights)) {
+			if weights[u] > 0 {
+				cfg.baseURLs = append(cfg.baseURLs, u)
+			}
+		}
+		if len(cfg.baseURLs) > 0 {
+			cfg.baseURLWeights = weights
+		}
+	})
+}
+
+// WithLoadBalancingStrategy sets how a base URL of [WithBaseURLs] is picked
+// for every request, [RoundRobin] or [Random].
+//
+// default: [RoundRobin]
+func WithLoadBalancingStrategy(strategy LoadBalancingStrategy) option {
+	return option(func(cfg *config) {
+		cfg.lbStrategy = strategy
+	})
+}
+
+// WithPathTimeouts sets the request timeout per path name, overriding the
+// client one and [resty.Request.SetTimeout], e.g. a longer timeout for a slow
+// reporting endpoint. A [WithRequestTimeout] timeout or a context deadline
+// takes precedence.
+func WithPathTimeouts(timeouts map[string]time.Duration) option {
+	return option(func(cfg *config) {
+		if timeouts != nil {
+			cfg.pathTimeouts = timeouts
+		}
+	})
+}
+
+// WithMethodTimeouts sets the request timeout per HTTP method, overriding the
+// client one and [resty.Request.SetTimeout], e.g. a short timeout for reads and
+// a longer one for writes. A [WithRequestTimeout] or [WithPathTimeouts]
+// timeout takes precedence.
+func WithMethodTimeouts(timeouts map[string]time.Duration) option {
+	return option(func(cfg *config) {
+		if timeouts != nil {
+			cfg.methodTimeouts = timeouts
+		}
+	})
+}
+
+// WithContentTypeDetectionEnabled sniffs the "Content-Type" of requests whose
+// body is a raw []byte or string that isn't valid JSON, instead of sending the
+// default "application/json", so e.g. a CSV upload isn't mislabeled as JSON.
+// An explicitly set non-JSON "Content-Type" is always kept.
+func WithContentTypeDetectionEnabled(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.ctDetectionEnabled = enabled
+	})
+}
+
+// WithDisableContentTypeSniffing makes a response whose "Content-Type" isn't
+// JSON fail with [ErrUnexpectedContentType] when a result (or error) value is
+// set to decode it into, instead of a best-effort decode, e.g. to catch an
+// upstream returning a text/plain error page. A missing "Content-Type" is an
+// error too.
+func WithDisableContentTypeSniffing(disable bool) option {
+	return option(func(cfg *config) {
+		cfg.strictContentType = disable
+	})
+}
+
+// WithAssumeJSON decodes responses without a "Content-Type" header as JSON,
+// otherwise the result (or error) value isn't decoded. A per-request
+// [resty.Request.SetExpectResponseContentType] takes precedence.
+func WithAssumeJSON(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.assumeJSON = enabled
+	})
+}
+
+// WithResponseContentTypeOverride decodes the responses of the path names in
+// contentTypes with their content type, e.g. "application/json" for a server
+// labelling JSON as text/plain, whatever the response "Content-Type". A
+// per-request [resty.Request.SetForceResponseContentType] takes precedence.
+func WithResponseContentTypeOverride(contentTypes map[string]string) option {
+	return option(func(cfg *config) {
+		if contentTypes != nil {
+			cfg.resContentTypes = contentTypes
+		}
+	})
+}
+
+// WithMaxResponseBodySize limits the uncompressed response body size in bytes,
+// reading a larger body fails with [resty.ErrReadExceedsThresholdLimit].
+//
+// default: 0 (unlimited)
+func WithMaxResponseBodySize(size int64) option {
+	return option(func(cfg *config) {
+		cfg.maxResponseBodySize = size
+	})
+}
+
+// WithResponseErrorBodyMaxSize truncates the decompressed body of 4xx and 5xx
+// responses to size bytes, bounding the memory used by a misbehaving server's
+// error path independently of [WithMaxResponseBodySize]. Decoding a truncated
+// error body doesn't fail the request, the error value is decoded as far as
+// possible, see [StatusError].Truncated.
+//
+// default: 0 (unlimited)
+func WithResponseErrorBodyMaxSize(size int64) option {
+	return option(func(cfg *config) {
+		cfg.errorBodyMaxSize = size
+	})
+}
+
+// WithResponseDecodeTimeout limits the time spent decoding a JSON response
+// body, e.g. a huge or slowly streamed payload. Exceeding it fails the request
+// with [ErrResponseDecodeTimeout].
+//
+// default: 0 (unlimited)
+func WithResponseDecodeTimeout(d time.Duration) option {
+	return option(func(cfg *config) {
+		cfg.decodeTimeout = d
+	})
+}
+
+// WithResponseTransformer transforms the raw JSON response body before it's
+// decoded into the result (or error) value, e.g. to trim a BOM or unwrap JSONP
+// from a flaky third-party API. An error from f fails the request.
+func WithResponseTransformer(f func(raw []byte) ([]byte, error)) option {
+	return option(func(cfg *config) {
+		if f != nil {
+			cfg.resTransformer = f
+		}
+	})
+}
+
+// WithUseNumber decodes JSON numbers into an interface{} (e.g. a map[string]any
+// result) as json.Number instead of float64, preventing the precision loss of
+// large integer IDs.
+func WithUseNumber(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.useNumber = enabled
+	})
+}
+
+// WithRequestContextTimeoutError returns [ErrRequestTimeout] from the verb call
+// when the request deadline is exceeded, whether it fails the round trip, the
+// response decode or a retry wait, instead of a generic transport error.
+func WithRequestContextTimeoutError(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.mapTimeoutErr = enabled
+	})
+}
+
+// WithResponseIdleReadTimeout fails reading a response body with
+// [ErrResponseIdleTimeout] when no bytes arrive for d, e.g. a stalled
+// connection during a large download. Unlike the total timeout, it's reset
+// whenever bytes arrive, so a slow but steady stream isn't cut.
+func WithResponseIdleReadTimeout(d time.Duration) option {
+	return option(func(cfg *config) {
+		cfg.resIdleTimeout = d
+	})
+}
+
+// WithCaptureRawResponse retains the raw response body in memory, so it can be
+// read with [RawResponseBody] after being decoded, e.g. for debugging. Combine
+// it with [WithMaxResponseBodySize] to bound the memory usage.
+func WithCaptureRawResponse(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.captureRawResponse = enabled
+	})
+}
+
+// WithResponseNullHandling retains the raw response body like
+// [WithCaptureRawResponse], so [NullFields] tells the fields explicitly null
+// from the absent ones, both decoded into zero values, e.g. for partial update
+// APIs.
+func WithResponseNullHandling(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.resNullFields = enabled
+	})
+}
+
+// WithResponseBodyReplay buffers response bodies up to maxSize bytes so every
+// response middleware can read the full body, with [ResponseBodyReader], in
+// addition to it being decoded into the result value, e.g. to layer logging,
+// validation and transformation. A larger body fails the request with
+// [resty.ErrReadExceedsThresholdLimit], a smaller [WithMaxResponseBodySize]
+// takes precedence.
+func WithResponseBodyReplay(maxSize int64) option {
+	return option(func(cfg *config) {
+		cfg.replayMaxSize = maxSize
+	})
+}
+
+// WithResponseCacheEnabled caches GET responses in memory, serving them while
+// fresh per their "Cache-Control" max-age and revalidating stale ones with
+// their "ETag". The responses marked "no-store" or "private", to a request with
+// an "Authorization" header, or with a body larger than
+// [WithMaxResponseBodySize] (1MB when it isn't set) aren't cached. A response
+// is only served to the requests matching its "Vary" headers, and past 1000
+// responses the least recently used one is evicted. Purge entries with
+// [Client.InvalidateCache] and [Client.InvalidateCachePrefix].
+func WithResponseCacheEnabled(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.cache = nil
+		if enabled {
+			cfg.cache = newResponseCache()
+		}
+	})
+}
+
+// WithOnResponseBytes calls f with the raw bytes of every response body, copied
+// as the body is read, e.g. for audit logging or checksum verification. f is
+// called once the body is read to its end or to the bound, or closed after a
+// partial read, so streamed bodies aren't delayed. The bytes are bounded by
+// [WithMaxResponseBodySize], or 1MB when it isn't set, and f must not retain
+// or modify them.
+func WithOnResponseBytes(f func(ctx context.Context, b []byte)) option {
+	return option(func(cfg *config) {
+		if f != nil {
+			cfg.onResponseBytes = f
+		}
+	})
+}
+
+// WithStartupProbe sends a HEAD request to the path registered with pathName,
+// or the base URL when it's empty, of every base URL, including the ones of
+// [WithBaseURLs], from [NewClient] to fail fast on a misconfigured or
+// unreachable server. [NewClientE] returns the error, [NewClient] logs it and
+// returns it from [Client.StartupError]. Any response passes the probe, an
+// unregistered pathName fails it.
+//
+// default: disabled, timeout default: 5s
+func WithStartupProbe(pathName string, timeout time.Duration) option {
+	return option(func(cfg *config) {
+		if timeout <= 0 {
+			timeout = 5 * time.Second
+		}
+		cfg.startupProbe = &startupProbe{pathName: pathName, timeout: timeout}
+	})
+}
+
+// WithAutomaticContentLength sends a "Content-Length" header for the
+// [io.Reader] bodies whose size is known, regular files and readers with a
+// Len() int method reporting their unread length, instead of the chunked
+// transfer encoding some servers reject. See [SizedReader] for any other
+// reader.
+//
+// default: false
+func WithAutomaticContentLength(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.autoContentLength = enabled
+	})
+}
+
+// WithRequestBodyValidator rejects a request before it's sent when validate
+// returns an error for its body, e.g. to enforce an API contract client-side.
+// The verb call then returns [ErrInvalidRequestBody] wrapping that error.
+//
+// validate is called with the value given to [resty.Request.SetBody] before
+// any other request middleware, so a rejected request isn't logged, traced or
+// counted, requests without a body aren't validated.
+func WithRequestBodyValidator(validate func(body any) error) option {
+	return option(func(cfg *config) {
+		if validate != nil {
+			cfg.reqBodyValidator = validate
 		}
 	})
 }
@@ -60,12 +900,108 @@ func WithLogger(l *slog.Logger) option {
 	})
 }
 
+// WithLoggerFromContext logs requests and responses with the logger f returns
+// for the request context, e.g. one enriched by the web framework with the
+// incoming request attributes, falling back to the client logger when f
+// returns nil.
+func WithLoggerFromContext(f func(ctx context.Context) *slog.Logger) option {
+	return option(func(cfg *config) {
+		if f != nil {
+			cfg.ctxLogger = f
+		}
+	})
+}
+
+// WithDefaultLogFormat logs to [os.Stdout] with the recommended handler in
+// format, see [NewLogHandler], instead of assembling one for [WithLogger].
+func WithDefaultLogFormat(format LogFormat) option {
+	return option(func(cfg *config) {
+		cfg.logger = slog.New(NewLogHandler(format, os.Stdout))
+	})
+}
+
 func WithLogMWEnabled(enabled bool) option {
 	return option(func(cfg *config) {
 		cfg.logMWEnabled = enabled
 	})
 }
 
+// WithLogBodyOnErrorOnly logs the response body of error responses only, the
+// decoded error value or the raw body when there's none, cutting the log volume
+// of chatty successful calls.
+func WithLogBodyOnErrorOnly(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.logBodyOnErrorOnly = enabled
+	})
+}
+
+// WithLogBaggageKeys logs the OpenTelemetry baggage members of the given keys,
+// e.g. "tenant.id", as "baggage.<key>" attributes of the request and response
+// logs, correlating logs the way traces are. The other members aren't logged,
+// the baggage may carry values not meant for the logs.
+//
+// default: nil (no baggage logged)
+func WithLogBaggageKeys(keys []string) option {
+	return option(func(cfg *config) {
+		cfg.logBaggageKeys = keys
+	})
+}
+
+// WithMaxLogBodyDepth replaces the JSON objects and arrays of the logged request
+// and response bodies nested deeper than n with "{...}" and "[...]", the top
+// level being depth 1, bounding the log size of recursive payloads. It only
+// applies to the logged copy.
+//
+// default: 0 (no limit)
+func WithMaxLogBodyDepth(n int) option {
+	return option(func(cfg *config) {
+		cfg.maxLogBodyDepth = n
+	})
+}
+
+// WithRequestLogSampleRate logs only a fraction, between 0 and 1, of the
+// outgoing requests with the log middleware, e.g. when request bodies are
+// large. It's independent of [WithResponseLogSampleRate].
+//
+// default: 1 (every request)
+func WithRequestLogSampleRate(rate float64) option {
+	return option(func(cfg *config) {
+		cfg.reqLogSampleRate = &rate
+	})
+}
+
+// WithResponseLogSampleRate logs only a fraction, between 0 and 1, of the
+// incoming responses with the log middleware. It's independent of
+// [WithRequestLogSampleRate].
+//
+// default: 1 (every response)
+func WithResponseLogSampleRate(rate float64) option {
+	return option(func(cfg *config) {
+		cfg.resLogSampleRate = &rate
+	})
+}
+
+// WithRequestBodyLogFormatter transforms the request body before it is logged
+// by the log middleware, e.g. summarizing large arrays or hashing binary data.
+// It only applies to the logged copy, the body sent to the server is untouched.
+func WithRequestBodyLogFormatter(f func(body any) any) option {
+	return option(func(cfg *config) {
+		if f != nil {
+			cfg.reqBodyLogFormatter = f
+		}
+	})
+}
+
+// WithRequestCloneForLogging logs the content of io.Reader request bodies,
+// e.g. a stream, without consuming them for the request: a seekable reader is
+// rewound after being read, any other one is buffered in memory. The body
+// formatter of [WithRequestBodyLogFormatter] gets a copy of the reader.
+func WithRequestCloneForLogging(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.cloneReqBodyForLog = enabled
+	})
+}
+
 func WithTracer(t trace.TracerProvider) option {
 	return option(func(cfg *config) {
 		if t != nil {
@@ -82,32 +1018,220 @@ func WithPropagator(p propagation.TextMapPropagator) option {
 	})
 }
 
+// WithTraceAllErrorsAsEvents records the error of every retried attempt of a
+// request as an exception event with its "httpz.attempt" number on the span
+// ending the request, not only the last error, to debug flaky calls.
+func WithTraceAllErrorsAsEvents(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.traceAttemptErrors = enabled
+	})
+}
+
 func WithOtelMWEnabled(enabled bool) option {
 	return option(func(cfg *config) {
 		cfg.otelMWEnabled = enabled
 	})
 }
 
+// WithPeerService sets the "peer.service" span attribute, the name of the
+// remote service (e.g. "payments-api") used to label dependency graphs.
+//
+// default: the client name
+func WithPeerService(service string) option {
+	return option(func(cfg *config) {
+		cfg.peerService = service
+	})
+}
+
+// WithSpanErrorOn4xx controls whether a 4xx response marks the request span
+// status as Error. Passing false leaves it Unset, for APIs where e.g. a 404 is
+// an expected outcome rather than a failure. A 5xx response is always an Error.
+//
+// default: true
+func WithSpanErrorOn4xx(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.span4xxNotError = !enabled
+	})
+}
+
+// WithServerTimingEnabled parses the "Server-Timing" response header, see
+// [ParseServerTiming], recording its metrics as span events with the otel
+// middleware and as the "http.response.server_timing" field with the log
+// middleware.
+func WithServerTimingEnabled(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.serverTimingEnabled = enabled
+	})
+}
+
+func WithMeter(m metric.MeterProvider) option {
+	return option(func(cfg *config) {
+		if m != nil {
+			cfg.meter = m
+		}
+	})
+}
+
+// WithMetricsMWEnabled enables the opentelemetry metrics middleware, it records:
+//   - http.client.active_requests - number of in-flight requests by method
+//   - http.client.request.duration - request duration in seconds by method and status class
+//   - http.client.response.body.size - response body size by method and status class
+func WithMetricsMWEnabled(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.metricsMWEnabled = enabled
+	})
+}
+
+// WithMetricsForCircuitBreakerDenials counts the requests refused by the open
+// circuit breaker or throttled by its [WithCircuitBreakerWarmup], labeled by
+// path name and reason, "open" or "warmup", apart from the failed calls,
+// requires [WithMetricsMWEnabled].
+func WithMetricsForCircuitBreakerDenials(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.cbDenialMetrics = enabled
+	})
+}
+
+// WithLatencyStatsEnabled keeps an in-process latency summary per path name,
+// read with [Client.LatencyStats]. Only the requests to a path template given
+// to [WithPaths], or to a path returned by [Client.GetPathContext], are
+// recorded. A template shared by several path names is recorded under the
+// first name in sorted order.
+func WithLatencyStatsEnabled(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.latency = nil
+		if enabled {
+			cfg.latency = newLatencyRecorder()
+		}
+	})
+}
+
+// WithMetricsNamespace prefixes the metric names recorded by the metrics
+// middleware, e.g. "myservice." records "myservice.http.client.active_requests",
+// to avoid collisions between clients sharing a meter provider.
+//
+// default: "" (no prefix)
+func WithMetricsNamespace(namespace string) option {
+	return option(func(cfg *config) {
+		cfg.metricsNamespace = namespace
+	})
+}
+
+// WithMetricsDurationBuckets sets the http.client.request.duration histogram
+// bucket boundaries in seconds, e.g. finer buckets for low-latency internal
+// calls.
+//
+// default: 0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10
+func WithMetricsDurationBuckets(buckets []float64) option {
+	return option(func(cfg *config) {
+		if len(buckets) > 0 {
+			cfg.durationBuckets = buckets
+		}
+	})
+}
+
+// WithObservability sets the logger, tracer provider and meter provider, and
+// enables the log, opentelemetry tracing and metrics middlewares in one call.
+// A nil argument falls back to its default, as with [WithLogger], [WithTracer]
+// and [WithMeter].
+func WithObservability(l *slog.Logger, tp trace.TracerProvider, mp metric.MeterProvider) option {
+	return option(func(cfg *config) {
+		for _, opt := range []option{
+			WithLogger(l),
+			WithTracer(tp),
+			WithMeter(mp),
+			WithLogMWEnabled(true),
+			WithOtelMWEnabled(true),
+			WithMetricsMWEnabled(true),
+		} {
+			opt(cfg)
+		}
+	})
+}
+
 func WithServiceVersion(version string) option {
 	return option(func(cfg *config) {
 		cfg.serviceVersion = version
 	})
 }
 
+// WithRetryIdempotentOnly restricts retries to idempotent methods (GET, HEAD,
+// OPTIONS, PUT, DELETE, TRACE) so a retried POST or PATCH can't accidentally
+// duplicate a write. Passing false allows retrying every method.
+//
+// default: true
+func WithRetryIdempotentOnly(idempotentOnly bool) option {
+	return option(func(cfg *config) {
+		cfg.retryNonIdempotent = !idempotentOnly
+	})
+}
+
+// WithRetryBudget rate limits the retries of the whole client, preventing
+// retry amplification during a widespread outage: retries are allowed up to
+// ratio of the requests, e.g. 0.1 for 10%, plus minPerSec retries per second
+// so a low traffic client can still retry. Once the budget is exhausted a
+// request returns its last response (or error) even if retry attempts remain,
+// without waiting for the next retry.
+//
+// Like [WithMaxRetryElapsedTime], the resty default retry conditions are
+// replaced by an equivalent condition checking the budget.
+//
+// default: disabled
+func WithRetryBudget(ratio float64, minPerSec int) option {
+	return option(func(cfg *config) {
+		cfg.retryTokens = newRetryTokenBucket(max(ratio, 0), max(minPerSec, 0))
+	})
+}
+
+// WithRetryResetReader buffers the non-seekable [io.Reader] bodies of the
+// requests which may be retried, so every attempt resends the full body
+// instead of the already consumed reader. Seekable bodies, e.g. *[os.File],
+// are rewound by resty and never buffered.
+//
+// default: false
+func WithRetryResetReader(enabled bool) option {
+	return option(func(cfg *config) {
+		cfg.retryResetReader = enabled
+	})
+}
+
+// WithMaxRetryElapsedTime stops retrying once d has elapsed since the first
+// attempt, even if retry attempts remain, returning the last response (or
+// error) without waiting for the next retry. It bounds long retry chains
+// without lowering the retry count.
+//
+// The resty default retry conditions are replaced by an equivalent condition
+// checking the budget, a retry condition added to the client can still allow
+// a retry the budget denies.
+//
+// default: 0 (unlimited)
+func WithMaxRetryElapsedTime(d time.Duration) option {
+	return option(func(cfg *config) {
+		cfg.maxRetryElapsed = d
+	})
+}
+
 // WithCircuitBreaker accepts:
 //   - timeout - duration window for circuit breaker to determine the state
 //   - failureThreshold - number of failures that must occur within the timeout duration to transition to Open state
 //   - successThreshold - number of successes that must occur to transition from Half-Open state to Closed state
 //   - policies - determine whether a request is failed or successful by evaluating the response instance
 //
-// passing zero values will result to default values: 10s, 3, 1, Status Code 500 and above
+// passing zero values will result to default values: 10s, 3, 1, [PolicyServerAndRateLimit]
+//
+// A response is a failure when any policy returns true, see [PolicyServerErrors],
+// [PolicyServerAndRateLimit] and [PolicyAllNon2xx] for common ones. The default
+// policy ignores 4xx client errors other than 429, as they're caller bugs
+// (e.g. validation errors) rather than a dependency failure.
 func WithCircuitBreaker(
 	timeout time.Duration,
 	failureThreshold, successThreshold uint32,
 	policies ...func(*http.Response) bool,
 ) option {
 	return option(func(cfg *config) {
-		cfg.circuitBreaker = resty.NewCircuitBreaker()
+		cfg.circuitBreaker = resty.NewCircuitBreaker().
+			SetPolicies(PolicyServerAndRateLimit)
+		cfg.cbTimeout, cfg.cbFailureThreshold, cfg.cbPolicies = timeout, failureThreshold, nil
 		if timeout > 0 {
 			cfg.circuitBreaker.SetTimeout(timeout)
 		}
@@ -122,6 +1246,7 @@ func WithCircuitBreaker(
 			for _, p := range policies {
 				if p != nil {
 					pp = append(pp, resty.CircuitBreakerPolicy(p))
+					cfg.cbPolicies = append(cfg.cbPolicies, p)
 				}
 			}
 			if len(pp) > 0 {
@@ -131,6 +1256,23 @@ func WithCircuitBreaker(
 	})
 }
 
+// WithCircuitBreakerWarmup ramps up the admitted traffic linearly over d once
+// the circuit breaker recovers from the open state, so a burst of requests
+// can't immediately trip it again. Throttled requests fail with
+// [ErrCircuitBreakerWarmup].
+//
+// A recovery is detected as the first request admitted after the breaker
+// rejected one with [resty.ErrCircuitBreakerOpen].
+//
+// default: 0 (disabled)
+func WithCircuitBreakerWarmup(d time.Duration) option {
+	return option(func(cfg *config) {
+		if d > 0 {
+			cfg.cbWarmup = &cbWarmup{duration: d}
+		}
+	})
+}
+
 func WithCircuitBreakerEnabled(enabled bool) option {
 	return option(func(cfg *config) {
 		cfg.circuitBreakerEnabled = enabled
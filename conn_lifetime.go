@@ -0,0 +1,104 @@
+package httpz
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// lifetimeConn is closed once it's older than its lifetime, right away when
+// idle or as soon as its current request is done, so the transport dials a new
+// connection instead of reusing it.
+type lifetimeConn struct {
+	net.Conn
+	timer *time.Timer
+
+	mu      sync.Mutex
+	busy    bool
+	expired bool
+}
+
+func newLifetimeConn(conn net.Conn, lifetime time.Duration) *lifetimeConn {
+	// a new connection is dialed for a request, it's busy until released
+	c := &lifetimeConn{Conn: conn, busy: true}
+	c.timer = time.AfterFunc(lifetime, c.expire)
+	return c
+}
+
+func (c *lifetimeConn) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expired = true
+	if !c.busy {
+		_ = c.Conn.Close()
+	}
+}
+
+func (c *lifetimeConn) acquire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busy = true
+}
+
+func (c *lifetimeConn) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busy = false
+	if c.expired {
+		_ = c.Conn.Close()
+	}
+}
+
+func (c *lifetimeConn) Close() error {
+	c.timer.Stop()
+	return c.Conn.Close()
+}
+
+// lifetimeDialer wraps dial so every connection is a *lifetimeConn.
+func lifetimeDialer(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+	lifetime time.Duration,
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return newLifetimeConn(conn, lifetime), nil
+	}
+}
+
+// connLifetimeTransport tracks when a *lifetimeConn is used by a request and
+// when it's returned to the idle pool, the transport doesn't expose it.
+type connLifetimeTransport struct {
+	next http.RoundTripper
+}
+
+func (t *connLifetimeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var conn *lifetimeConn
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			c := info.Conn
+			if tlsConn, ok := c.(*tls.Conn); ok {
+				c = tlsConn.NetConn()
+			}
+			if lc, ok := c.(*lifetimeConn); ok {
+				conn = lc
+				conn.acquire()
+			}
+		},
+		PutIdleConn: func(error) {
+			if conn != nil {
+				conn.release()
+			}
+		},
+	}
+	return t.next.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
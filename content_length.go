@@ -0,0 +1,79 @@
+package httpz
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	"resty.dev/v3"
+)
+
+// sizedReader is a request body of known size, see [SizedReader].
+type sizedReader struct {
+	io.Reader
+	size int64
+}
+
+// SizedReader returns a request body reading size bytes from r, sent with a
+// "Content-Length" header instead of the chunked transfer encoding used for an
+// [io.Reader] of unknown size, which some servers reject.
+//
+//	req.SetBody(httpz.SizedReader(upload, header.Size))
+//
+// r must provide exactly size bytes, otherwise the request fails.
+func SizedReader(r io.Reader, size int64) io.Reader {
+	return &sizedReader{Reader: r, size: size}
+}
+
+// setReaderContentLength sets the content length of an [io.Reader] body of
+// known size, net/http only knows the size of *bytes.Buffer, *bytes.Reader and
+// *strings.Reader bodies.
+func setReaderContentLength(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if req.RawRequest == nil || req.RawRequest.Body == nil || req.RawRequest.ContentLength > 0 {
+			return nil
+		}
+
+		size, ok := readerSize(cfg, req.Body)
+		if !ok {
+			return nil
+		}
+		if size == 0 {
+			req.RawRequest.Body = http.NoBody
+		}
+		req.RawRequest.ContentLength = size
+
+		return nil
+	}
+}
+
+// readerSize returns the number of bytes left to read from body, it's known
+// for a [SizedReader] and, with [WithAutomaticContentLength], for a reader
+// reporting its unread length or a regular file.
+func readerSize(cfg *config, body any) (int64, bool) {
+	switch r := body.(type) {
+	case *sizedReader:
+		return r.size, true
+	case interface{ Len() int }:
+		if cfg.autoContentLength {
+			return int64(r.Len()), true
+		}
+	case *os.File:
+		if cfg.autoContentLength {
+			return fileSize(r)
+		}
+	}
+	return 0, false
+}
+
+func fileSize(f *os.File) (int64, bool) {
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0, false
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false
+	}
+	return info.Size() - offset, true
+}
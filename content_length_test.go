@@ -0,0 +1,80 @@
+package httpz
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderContentLength(t *testing.T) {
+	var gotLength int64
+	var gotEncoding []string
+	var gotBody string
+	server := startTestServer(t, testHandler{
+		method: http.MethodPut,
+		path:   "/test/upload",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			gotLength, gotEncoding = r.ContentLength, r.TransferEncoding
+			b, _ := io.ReadAll(r.Body)
+			gotBody = string(b)
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	paths := map[string]string{"upload": "/test/upload"}
+	// a reader of unknown size for net/http
+	streamed := func() io.Reader {
+		return io.MultiReader(strings.NewReader("hello "), strings.NewReader("world"))
+	}
+
+	t.Run("sized reader", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths))
+
+		_, err := client.NewRequest(context.Background()).
+			SetBody(SizedReader(streamed(), 11)).
+			Put(client.GetPath("upload"))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), gotLength)
+		assert.Empty(t, gotEncoding)
+		assert.Equal(t, "hello world", gotBody)
+	})
+
+	t.Run("unsized reader", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths))
+
+		_, err := client.NewRequest(context.Background()).
+			SetBody(streamed()).
+			Put(client.GetPath("upload"))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), gotLength)
+		assert.Equal(t, []string{"chunked"}, gotEncoding)
+	})
+
+	t.Run("automatic file size", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "upload.txt")
+		require.NoError(t, os.WriteFile(name, []byte("hello world"), 0o600))
+		f, err := os.Open(name)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = f.Close() })
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithAutomaticContentLength(true),
+		)
+
+		_, err = client.NewRequest(context.Background()).
+			SetBody(f).
+			Put(client.GetPath("upload"))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), gotLength)
+		assert.Equal(t, "hello world", gotBody)
+	})
+}
@@ -0,0 +1,106 @@
+package httpz
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"resty.dev/v3"
+)
+
+// ErrUnexpectedContentType is returned from the verb call when
+// [WithDisableContentTypeSniffing] is enabled and a response expected to be
+// decoded isn't declared as JSON.
+var ErrUnexpectedContentType = errors.New("httpz: unexpected response content type")
+
+// detectContentType replaces the default "application/json" header set by
+// [Client.NewRequest] with a sniffed one when the body is a raw []byte or
+// string that isn't valid JSON.
+func detectContentType(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if !cfg.ctDetectionEnabled {
+			return nil
+		}
+
+		var b []byte
+		switch body := req.Body.(type) {
+		case []byte:
+			b = body
+		case string:
+			b = []byte(body)
+		default:
+			return nil
+		}
+
+		if !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") || json.Valid(b) {
+			return nil
+		}
+		req.Header.Set("Content-Type", http.DetectContentType(b))
+
+		return nil
+	}
+}
+
+// assumeJSON decodes responses without a "Content-Type" as JSON, unless the
+// request already sets an expected response content type.
+func assumeJSON(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.assumeJSON && req.ExpectResponseContentType == "" {
+			req.SetExpectResponseContentType("application/json")
+		}
+
+		return nil
+	}
+}
+
+// overrideResponseContentType decodes the responses of a path name with the
+// content type of [WithResponseContentTypeOverride] whatever their
+// "Content-Type", unless the request already forces one.
+func overrideResponseContentType(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if len(cfg.resContentTypes) == 0 || req.ForceResponseContentType != "" {
+			return nil
+		}
+
+		if name, ok := lookupPathName(cfg, req.URL); ok {
+			if ct := cfg.resContentTypes[name]; ct != "" {
+				req.SetForceResponseContentType(ct)
+			}
+		}
+
+		return nil
+	}
+}
+
+// checkResponseContentType fails a response with a result (or error) value to
+// decode whose "Content-Type" isn't JSON, instead of resty silently skipping
+// the decode or falling back to the expected content type.
+func checkResponseContentType(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		if !cfg.strictContentType || res.StatusCode() == http.StatusNoContent {
+			return nil
+		}
+
+		req := res.Request
+		if req.ForceResponseContentType != "" {
+			return nil
+		}
+		if !(res.IsSuccess() && req.Result != nil) && !(res.IsError() && req.Error != nil) {
+			return nil
+		}
+
+		ct := res.Header().Get("Content-Type")
+		if ct == "" && cfg.assumeJSON {
+			return nil
+		}
+		mediaType, _, _ := mime.ParseMediaType(ct)
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			return nil
+		}
+
+		return fmt.Errorf("%w: %q", ErrUnexpectedContentType, ct)
+	}
+}
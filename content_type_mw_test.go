@@ -0,0 +1,285 @@
+package httpz
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectContentType(t *testing.T) {
+	wantCSV := "name,age\nalice,30\nbob,25\n"
+	gotContentType := ""
+	server := startTestServer(t, testHandler{
+		method: http.MethodPost,
+		path:   "/test/upload",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			gotContentType = r.Header.Get("Content-Type")
+
+			_, err := io.ReadAll(r.Body)
+
+			assert.NoError(t, err)
+
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	paths := map[string]string{"upload": "/test/upload"}
+
+	t.Run("detection disabled forces json", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths))
+
+		res, err := client.NewRequest(context.Background()).
+			SetBody(wantCSV).
+			Post(client.GetPath("upload"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "application/json", gotContentType)
+	})
+
+	t.Run("detection enabled csv string", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithContentTypeDetectionEnabled(true),
+		)
+
+		res, err := client.NewRequest(context.Background()).
+			SetBody(wantCSV).
+			Post(client.GetPath("upload"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "text/plain; charset=utf-8", gotContentType)
+	})
+
+	t.Run("detection enabled binary bytes", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithContentTypeDetectionEnabled(true),
+		)
+
+		res, err := client.NewRequest(context.Background()).
+			SetBody([]byte("%PDF-1.7\n")).
+			Post(client.GetPath("upload"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "application/pdf", gotContentType)
+	})
+
+	t.Run("detection enabled raw json string", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithContentTypeDetectionEnabled(true),
+		)
+
+		res, err := client.NewRequest(context.Background()).
+			SetBody(`{"name":"alice"}`).
+			Post(client.GetPath("upload"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "application/json", gotContentType)
+	})
+
+	t.Run("detection enabled explicit content type", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithContentTypeDetectionEnabled(true),
+		)
+
+		res, err := client.NewRequest(context.Background()).
+			SetContentType("text/csv").
+			SetBody(wantCSV).
+			Post(client.GetPath("upload"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "text/csv", gotContentType)
+	})
+}
+
+func TestDisableContentTypeSniffing(t *testing.T) {
+	type testRes struct {
+		Status string `json:"status"`
+	}
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/json",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"status":"ok"}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/text",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"status":"ok"}`))
+			},
+		},
+	)
+	paths := map[string]string{
+		"json": "/test/json",
+		"text": "/test/text",
+	}
+
+	t.Run("sniffing enabled ignores mismatch", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths))
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(&testRes{}).
+			Get(client.GetPath("text"))
+
+		require.NoError(t, err)
+	})
+
+	t.Run("strict mode accepts json", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithDisableContentTypeSniffing(true),
+		)
+		result := &testRes{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(result).
+			Get(client.GetPath("json"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result.Status)
+	})
+
+	t.Run("strict mode rejects text/plain", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithDisableContentTypeSniffing(true),
+		)
+
+		res, err := client.NewRequest(context.Background()).
+			SetResult(&testRes{}).
+			Get(client.GetPath("text"))
+
+		require.ErrorIs(t, err, ErrUnexpectedContentType)
+		assert.ErrorContains(t, err, `"text/plain"`)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+	})
+
+	t.Run("strict mode without result", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithDisableContentTypeSniffing(true),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("text"))
+
+		require.NoError(t, err)
+	})
+}
+
+func TestAssumeJSON(t *testing.T) {
+	type testRes struct {
+		Status string `json:"status"`
+	}
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/no-content-type",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header()["Content-Type"] = nil // disable content type sniffing
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		},
+	})
+	paths := map[string]string{"noContentType": "/test/no-content-type"}
+
+	t.Run("disabled", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths))
+		result := &testRes{}
+
+		res, err := client.NewRequest(context.Background()).
+			SetResult(result).
+			Get(client.GetPath("noContentType"))
+
+		require.NoError(t, err)
+		assert.Empty(t, res.Header().Get("Content-Type"))
+		assert.Empty(t, result.Status)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithAssumeJSON(true),
+			WithDisableContentTypeSniffing(true),
+		)
+		result := &testRes{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(result).
+			Get(client.GetPath("noContentType"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result.Status)
+	})
+}
+
+func TestResponseContentTypeOverride(t *testing.T) {
+	type testRes struct {
+		ID string `json:"id"`
+	}
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/text-json",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"id":"1"}`))
+		},
+	})
+	paths := map[string]string{"textJSON": "/test/text-json"}
+
+	t.Run("per path", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithResponseContentTypeOverride(map[string]string{"textJSON": "application/json"}),
+		)
+		result := &testRes{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(result).
+			Get(client.GetPath("textJSON"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "1", result.ID)
+	})
+
+	t.Run("per request", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths))
+		result := &testRes{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetForceResponseContentType("application/json").
+			SetResult(result).
+			Get(client.GetPath("textJSON"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "1", result.ID)
+	})
+
+	t.Run("no override", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths))
+		result := &testRes{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(result).
+			Get(client.GetPath("textJSON"))
+
+		require.NoError(t, err)
+		assert.Empty(t, result.ID)
+	})
+}
@@ -0,0 +1,68 @@
+package httpz
+
+import (
+	"context"
+	"time"
+)
+
+type ctxKey int
+
+const (
+	clientIPKey ctxKey = iota
+	suppressTelemetryKey
+	responseBodyKey
+	requestIDKey
+	responseDurationKey
+	requestTimeoutKey
+)
+
+// WithClientIP returns a copy of ctx carrying the original client IP, which is
+// forwarded to the server when [WithForwardedForFromContext] is set.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIPFromContext returns the client IP stored by [WithClientIP].
+func ClientIPFromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPKey).(string)
+	return ip, ok && ip != ""
+}
+
+// WithTelemetrySuppression returns a copy of ctx marking its requests as
+// internal, e.g. health checks or token refreshes, they are skipped by the log,
+// tracing and metrics middlewares.
+func WithTelemetrySuppression(ctx context.Context) context.Context {
+	return context.WithValue(ctx, suppressTelemetryKey, true)
+}
+
+func telemetrySuppressed(ctx context.Context) bool {
+	suppressed, _ := ctx.Value(suppressTelemetryKey).(bool)
+	return suppressed
+}
+
+// ResponseBodyFromContext returns the response body stored in the request
+// context by [WithResponseBodyToContext]: the decoded result or error value, or
+// the raw bytes when there's none.
+func ResponseBodyFromContext(ctx context.Context) (any, bool) {
+	body := ctx.Value(responseBodyKey)
+	return body, body != nil
+}
+
+// RequestIDFromContext returns the request ID generated by
+// [WithOutgoingRequestID], e.g. from the context of the response request.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok && id != ""
+}
+
+// WithRequestTimeout returns a copy of ctx setting the timeout of its requests,
+// taking precedence over the client timeout and [WithPathTimeouts], e.g. a
+// longer timeout for a single bulk export.
+func WithRequestTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey, d)
+}
+
+func requestTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(requestTimeoutKey).(time.Duration)
+	return d, ok && d > 0
+}
@@ -0,0 +1,133 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/goccy/go-json"
+	"resty.dev/v3"
+)
+
+// ErrResponseDecodeTimeout is returned from the verb call when decoding the
+// response body exceeds [WithResponseDecodeTimeout].
+var ErrResponseDecodeTimeout = errors.New("httpz: response decode timeout")
+
+func jsonDecoder(cfg *config) resty.ContentTypeDecoder {
+	return func(r io.Reader, v any) error {
+		ctx := context.Background()
+		if cfg.decodeTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.decodeTimeout)
+			defer cancel()
+			if c, ok := r.(io.Closer); ok {
+				// unblocks a read stalled on the response body
+				stop := context.AfterFunc(ctx, func() { _ = c.Close() })
+				defer stop()
+			}
+			r = &ctxReader{ctx: ctx, r: r}
+		}
+
+		if cfg.resTransformer != nil {
+			raw, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			if raw, err = cfg.resTransformer(raw); err != nil {
+				return fmt.Errorf("httpz: transform response: %w", err)
+			}
+			r = bytes.NewReader(raw)
+		}
+
+		dec := json.NewDecoder(r)
+		if cfg.useNumber {
+			dec.UseNumber()
+		}
+		err := dec.Decode(v)
+		if err != nil && ctx.Err() != nil {
+			// the decoder reports the failed read as a syntax error
+			return ErrResponseDecodeTimeout
+		}
+		return err
+	}
+}
+
+// StatusError is returned by [Client.DecodeError] for a 4xx or 5xx response,
+// and by the verb call for a status code not expected by [ExpectStatus].
+type StatusError struct {
+	StatusCode int
+	Status     string
+	// Body is the target the error body was decoded into.
+	Body any
+	// Truncated reports whether the error body exceeded
+	// [WithResponseErrorBodyMaxSize], Body is then decoded as far as possible.
+	Truncated bool
+}
+
+func (e *StatusError) Error() string {
+	return "httpz: error response: " + e.Status
+}
+
+// DecodeError decodes the body of a 4xx or 5xx response into target with the
+// client JSON decoder and returns it wrapped in a *[StatusError]. It returns
+// nil for any other response.
+//
+// When the request has a [resty.Request.SetError] error object, the body was
+// already decoded into it: it's copied into target when they have the same
+// type, otherwise it's the Body of the *[StatusError]. A body that can't be
+// read anymore, e.g. with [resty.Request.SetDoNotParseResponse], returns an
+// error.
+//
+//	if err := client.DecodeError(res, &validationErrs); err != nil {
+//		return err
+//	}
+func (c *Client) DecodeError(res *resty.Response, target any) error {
+	if res == nil || !res.IsError() {
+		return nil
+	}
+
+	truncated := isErrorBodyTruncated(res)
+	b := res.Bytes()
+	switch {
+	case len(b) == 0 && res.Error() != nil:
+		errObj := reflect.ValueOf(res.Error())
+		if t := reflect.ValueOf(target); target != nil && t.Kind() == reflect.Pointer &&
+			t.Type() == errObj.Type() && !t.IsNil() && !errObj.IsNil() {
+			t.Elem().Set(errObj.Elem())
+		} else {
+			target = res.Error()
+		}
+	case len(b) == 0 && (res.Request.DoNotParseResponse || res.RawResponse != nil && res.RawResponse.ContentLength > 0):
+		return errors.New("httpz: decode error response: body already read")
+	case len(b) > 0 && target != nil:
+		// a truncated body is partially decoded
+		if err := jsonDecoder(c.cfg)(bytes.NewReader(b), target); err != nil && !truncated {
+			return fmt.Errorf("httpz: decode error response: %w", err)
+		}
+	}
+
+	return &StatusError{
+		StatusCode: res.StatusCode(),
+		Status:     res.Status(),
+		Body:       target,
+		Truncated:  truncated,
+	}
+}
+
+// ctxReader fails every read once ctx is done, so a streaming decoder stops at
+// its next read instead of consuming the rest of a slow or huge body. A read
+// blocked on a stalled body is unblocked by closing the body.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if r.ctx.Err() != nil {
+		return 0, ErrResponseDecodeTimeout
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,299 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseDecodeTimeout(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/decode",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`[1,2,3]`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/decode/slow",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`[`))
+				for range 20 {
+					_, _ = w.Write([]byte(`1,`))
+					w.(http.Flusher).Flush()
+					select {
+					case <-r.Context().Done():
+						return
+					case <-time.After(20 * time.Millisecond):
+					}
+				}
+				_, _ = w.Write([]byte(`1]`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/decode/stalled",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`[1,`))
+				w.(http.Flusher).Flush()
+				select {
+				case <-r.Context().Done():
+				case <-time.After(2 * time.Second):
+				}
+			},
+		},
+	)
+	paths := map[string]string{
+		"decode":        "/test/decode",
+		"decodeSlow":    "/test/decode/slow",
+		"decodeStalled": "/test/decode/stalled",
+	}
+
+	t.Run("decodes within timeout", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithResponseDecodeTimeout(time.Second),
+		)
+		result := []int{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(&result).
+			Get(client.GetPath("decode"))
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 3}, result)
+	})
+
+	t.Run("slow body exceeds timeout", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithResponseDecodeTimeout(50*time.Millisecond),
+		)
+		result := []int{}
+
+		start := time.Now()
+		_, err := client.NewRequest(context.Background()).
+			SetResult(&result).
+			Get(client.GetPath("decodeSlow"))
+
+		require.ErrorIs(t, err, ErrResponseDecodeTimeout)
+		assert.Less(t, time.Since(start), 300*time.Millisecond)
+	})
+
+	t.Run("stalled body exceeds timeout", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithResponseDecodeTimeout(50*time.Millisecond),
+		)
+		result := []int{}
+
+		start := time.Now()
+		_, err := client.NewRequest(context.Background()).
+			SetResult(&result).
+			Get(client.GetPath("decodeStalled"))
+
+		require.ErrorIs(t, err, ErrResponseDecodeTimeout)
+		assert.Less(t, time.Since(start), 300*time.Millisecond)
+	})
+}
+
+func TestResponseTransformer(t *testing.T) {
+	type testRes struct {
+		Status string `json:"status"`
+	}
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/jsonp",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`callback({"status":"ok"});`))
+		},
+	})
+	paths := map[string]string{"jsonp": "/test/jsonp"}
+
+	t.Run("unwraps jsonp", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithResponseTransformer(func(raw []byte) ([]byte, error) {
+				raw = bytes.TrimSuffix(bytes.TrimSpace(raw), []byte(");"))
+				_, raw, _ = bytes.Cut(raw, []byte("("))
+				return raw, nil
+			}),
+		)
+		result := &testRes{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(result).
+			Get(client.GetPath("jsonp"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result.Status)
+	})
+
+	t.Run("transformer error", func(t *testing.T) {
+		errTransform := errors.New("transform failed")
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithResponseTransformer(func(raw []byte) ([]byte, error) {
+				return nil, errTransform
+			}),
+		)
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(&testRes{}).
+			Get(client.GetPath("jsonp"))
+
+		require.ErrorIs(t, err, errTransform)
+	})
+}
+
+func TestUseNumber(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/number",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"id":1234567890123456789}`))
+		},
+	})
+	paths := map[string]string{"number": "/test/number"}
+
+	t.Run("enabled keeps precision", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths), WithUseNumber(true))
+		result := map[string]any{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(&result).
+			Get(client.GetPath("number"))
+
+		require.NoError(t, err)
+		assert.Equal(t, json.Number("1234567890123456789"), result["id"])
+	})
+
+	t.Run("disabled decodes float64", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths))
+		result := map[string]any{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(&result).
+			Get(client.GetPath("number"))
+
+		require.NoError(t, err)
+		assert.IsType(t, float64(0), result["id"])
+	})
+}
+
+func TestDecodeError(t *testing.T) {
+	type validationErrors struct {
+		Errors []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodPost,
+			path:   "/test/validate",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = w.Write([]byte(`{"errors":[{"field":"email","message":"invalid email"}]}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/ok",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+	)
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{
+			"validate": "/test/validate",
+			"ok":       "/test/ok",
+		}),
+	)
+
+	t.Run("422 decodes into target", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).Post(client.GetPath("validate"))
+		require.NoError(t, err)
+		target := &validationErrors{}
+
+		err = client.DecodeError(res, target)
+
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusUnprocessableEntity, statusErr.StatusCode)
+		assert.Same(t, target, statusErr.Body)
+		require.Len(t, target.Errors, 1)
+		assert.Equal(t, "email", target.Errors[0].Field)
+		assert.Equal(t, "invalid email", target.Errors[0].Message)
+	})
+
+	t.Run("SetError object is copied into target", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).
+			SetError(&validationErrors{}).
+			Post(client.GetPath("validate"))
+		require.NoError(t, err)
+		target := &validationErrors{}
+
+		err = client.DecodeError(res, target)
+
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Same(t, target, statusErr.Body)
+		require.Len(t, target.Errors, 1)
+		assert.Equal(t, "email", target.Errors[0].Field)
+	})
+
+	t.Run("SetError object of another type is the body", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).
+			SetError(&map[string]any{}).
+			Post(client.GetPath("validate"))
+		require.NoError(t, err)
+
+		err = client.DecodeError(res, &validationErrors{})
+
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Same(t, res.Error(), statusErr.Body)
+	})
+
+	t.Run("unread body is an error", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).
+			SetDoNotParseResponse(true).
+			Post(client.GetPath("validate"))
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		err = client.DecodeError(res, &validationErrors{})
+
+		require.Error(t, err)
+		assert.NotErrorAs(t, err, new(*StatusError))
+	})
+
+	t.Run("2xx is a no-op", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("ok"))
+		require.NoError(t, err)
+
+		assert.NoError(t, client.DecodeError(res, &validationErrors{}))
+	})
+}
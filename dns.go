@@ -0,0 +1,118 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// dnsCache caches the addresses of a host resolved by lookupHost for ttl.
+type dnsCache struct {
+	ttl        time.Duration
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+func newDNSCache(
+	ttl time.Duration,
+	lookupHost func(ctx context.Context, host string) ([]string, error),
+) *dnsCache {
+	if lookupHost == nil {
+		lookupHost = net.DefaultResolver.LookupHost
+	}
+	return &dnsCache{ttl: ttl, lookupHost: lookupHost, entries: make(map[string]dnsEntry)}
+}
+
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	e, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.addrs, nil
+	}
+
+	addrs, err := c.lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return addrs, nil
+}
+
+func (c *dnsCache) evict(host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, host)
+}
+
+// dnsCacheDialer dials the cached addresses of the host, trying them in order.
+// When the lookup fails, or none of the cached addresses can be dialed, the
+// entry is evicted and dial is called with the host for a live lookup.
+func dnsCacheDialer(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+	cache *dnsCache,
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+
+		ips, err := cache.lookup(ctx, host)
+		if err != nil {
+			return dial(ctx, network, addr)
+		}
+
+		var errs []error
+		found := false
+		for _, ip := range ips {
+			if !matchesNetwork(network, ip) {
+				continue
+			}
+			found = true
+			conn, err := dial(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				return nil, errors.Join(errs...)
+			}
+		}
+		if !found {
+			return nil, &net.AddrError{Err: "no suitable address found", Addr: host}
+		}
+
+		cache.evict(host)
+		return dial(ctx, network, addr)
+	}
+}
+
+// matchesNetwork reports whether ip can be dialed with network.
+func matchesNetwork(network, ip string) bool {
+	is4 := net.ParseIP(ip).To4() != nil
+	switch network {
+	case "tcp4", "udp4":
+		return is4
+	case "tcp6", "udp6":
+		return !is4
+	default:
+		return true
+	}
+}
@@ -0,0 +1,85 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSCache(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	baseURL := "http://httpz.test:" + u.Port()
+
+	var lookups atomic.Int32
+	var lookupErr error
+	withLookupHost := option(func(cfg *config) {
+		cfg.dnsLookupHost = func(_ context.Context, host string) ([]string, error) {
+			lookups.Add(1)
+			if lookupErr != nil {
+				return nil, lookupErr
+			}
+			return []string{"127.0.0.1"}, nil
+		}
+	})
+	ttl := 100 * time.Millisecond
+
+	t.Run("one lookup within the ttl", func(t *testing.T) {
+		lookups.Store(0)
+		client := NewClient("test-client", baseURL,
+			WithTransport(&http.Transport{DisableKeepAlives: true}),
+			WithDNSCache(ttl),
+			withLookupHost,
+		)
+
+		for range 3 {
+			res, err := client.NewRequest(context.Background()).Get("/")
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode())
+		}
+		assert.Equal(t, int32(1), lookups.Load())
+
+		time.Sleep(ttl + 20*time.Millisecond)
+		_, err := client.NewRequest(context.Background()).Get("/")
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(2), lookups.Load())
+	})
+
+	t.Run("lookup failure falls back to a live lookup", func(t *testing.T) {
+		lookupErr = errors.New("lookup failed")
+		t.Cleanup(func() { lookupErr = nil })
+		var dialed []string
+		dialer := &net.Dialer{}
+		client := NewClient("test-client", baseURL,
+			WithTransport(&http.Transport{
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					dialed = append(dialed, addr)
+					return dialer.DialContext(ctx, network, server.Listener.Addr().String())
+				},
+			}),
+			WithDNSCache(ttl),
+			withLookupHost,
+		)
+
+		_, err := client.NewRequest(context.Background()).Get("/")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"httpz.test:" + u.Port()}, dialed)
+	})
+}
@@ -0,0 +1,72 @@
+package httpz
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"resty.dev/v3"
+)
+
+// limitErrorBody wraps the resty response body decoding middleware, the body
+// of 4xx and 5xx responses is truncated to [WithResponseErrorBodyMaxSize]
+// bytes once decompressed, so a compressed error body is bounded too.
+func limitErrorBody(cfg *config, decode resty.ResponseMiddleware) resty.ResponseMiddleware {
+	return func(c *resty.Client, res *resty.Response) error {
+		limit := cfg.errorBodyMaxSize
+		if limit <= 0 || res.RawResponse == nil || res.StatusCode() < http.StatusBadRequest ||
+			res.Body == nil || res.Body == http.NoBody {
+			return decode(c, res)
+		}
+
+		b, err := io.ReadAll(io.LimitReader(res.Body, limit+1))
+		_ = res.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		body := &errorBody{}
+		if int64(len(b)) > limit {
+			b = b[:limit]
+			body.truncated = true
+			res.RawResponse.ContentLength = limit
+			res.Header().Set("Content-Length", strconv.FormatInt(limit, 10))
+		}
+		body.Reader = bytes.NewReader(b)
+		res.Body = body
+		// resty may replace res.Body, the truncation is looked up on the raw one
+		res.RawResponse.Body = body
+
+		return decode(c, res)
+	}
+}
+
+type errorBody struct {
+	*bytes.Reader
+	truncated bool
+}
+
+func (*errorBody) Close() error { return nil }
+
+// isErrorBodyTruncated reports whether the error body of res was truncated.
+func isErrorBodyTruncated(res *resty.Response) bool {
+	if res.RawResponse == nil {
+		return false
+	}
+	body, ok := res.RawResponse.Body.(*errorBody)
+	return ok && body.truncated
+}
+
+// allowTruncatedErrorBody drops the error of decoding a truncated error body
+// into the request error value, which is left as far as the decoder got, so
+// the response is still returned with its status.
+func allowTruncatedErrorBody() resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		if res.Err != nil && isErrorBodyTruncated(res) {
+			res.Err = nil
+		}
+
+		return nil
+	}
+}
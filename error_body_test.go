@@ -0,0 +1,110 @@
+package httpz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unlimited-budget-ecommerce/httpz/httpztest"
+)
+
+func TestResponseErrorBodyMaxSize(t *testing.T) {
+	type problem struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	detail := strings.Repeat("a", 10_000)
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/error",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"title":"boom","detail":"` + detail + `"}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/ok",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"title":"` + detail + `"}`))
+			},
+		},
+	)
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{
+			"error": "/test/error",
+			"ok":    "/test/ok",
+		}),
+		WithResponseErrorBodyMaxSize(1024),
+	)
+
+	t.Run("truncated body does not fail the request", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).
+			SetError(&problem{}).
+			Get(client.GetPath("error"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode())
+		assert.IsType(t, &problem{}, res.Error())
+	})
+
+	t.Run("decode error yields a truncated status error", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("error"))
+		require.NoError(t, err)
+		assert.Len(t, res.Bytes(), 1024)
+
+		target := &problem{}
+		err = client.DecodeError(res, target)
+
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.True(t, statusErr.Truncated)
+		assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+		assert.Equal(t, "boom", target.Title)
+	})
+
+	t.Run("success body is not limited", func(t *testing.T) {
+		result := &problem{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(result).
+			Get(client.GetPath("ok"))
+
+		require.NoError(t, err)
+		assert.Equal(t, detail, result.Title)
+	})
+
+	t.Run("gzip body is limited once decompressed", func(t *testing.T) {
+		gzipServer := httptest.NewServer(httpztest.GzipHandler(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"title":"boom","detail":"` + detail + `"}`))
+			},
+		)))
+		t.Cleanup(gzipServer.Close)
+		client := NewClient("test-client", gzipServer.URL,
+			WithResponseErrorBodyMaxSize(1024),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get("/test/error")
+		require.NoError(t, err)
+		assert.Len(t, res.Bytes(), 1024)
+
+		target := &problem{}
+		err = client.DecodeError(res, target)
+
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.True(t, statusErr.Truncated)
+		assert.Equal(t, "boom", target.Title)
+	})
+}
@@ -0,0 +1,52 @@
+package httpz
+
+import (
+	"context"
+	"slices"
+
+	"resty.dev/v3"
+)
+
+type expectStatusCtxKey struct{}
+
+// ExpectStatus makes the verb call of req return a *[StatusError] when the
+// response status code isn't one of codes, its Body being the body decoded
+// into the [resty.Request.SetError] target of an error response or the
+// [resty.Request.SetResult] target of a successful one, or the raw body when
+// there is none.
+//
+//	res, err := httpz.ExpectStatus(client.NewRequest(ctx), http.StatusCreated).
+//		SetBody(user).
+//		Post(client.GetPath("createUser"))
+func ExpectStatus(req *resty.Request, codes ...int) *resty.Request {
+	return req.SetContext(context.WithValue(req.Context(), expectStatusCtxKey{}, codes))
+}
+
+// checkExpectedStatus fails the responses whose status code isn't expected by
+// [ExpectStatus].
+func checkExpectedStatus() resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		codes, ok := res.Request.Context().Value(expectStatusCtxKey{}).([]int)
+		if !ok || slices.Contains(codes, res.StatusCode()) {
+			return nil
+		}
+
+		// resty decodes the body into the error or result target, it can't be
+		// read anymore
+		var body any
+		switch {
+		case res.IsError() && res.Error() != nil:
+			body = res.Error()
+		case res.IsSuccess() && res.Result() != nil:
+			body = res.Result()
+		default:
+			body = res.String()
+		}
+		return &StatusError{
+			StatusCode: res.StatusCode(),
+			Status:     res.Status(),
+			Body:       body,
+			Truncated:  isErrorBodyTruncated(res),
+		}
+	}
+}
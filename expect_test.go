@@ -0,0 +1,89 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectStatus(t *testing.T) {
+	type errorBody struct {
+		Message string `json:"message"`
+	}
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodPost,
+			path:   "/test/expect/created",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"message":"created"}`))
+			},
+		},
+		testHandler{
+			method: http.MethodPost,
+			path:   "/test/expect/failed",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"message":"boom"}`))
+			},
+		},
+	)
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{
+			"created": "/test/expect/created",
+			"failed":  "/test/expect/failed",
+		}),
+	)
+
+	t.Run("allowed status", func(t *testing.T) {
+		res, err := ExpectStatus(client.NewRequest(context.Background()), http.StatusOK, http.StatusCreated).
+			Post(client.GetPath("created"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode())
+	})
+
+	t.Run("unexpected status", func(t *testing.T) {
+		res, err := ExpectStatus(client.NewRequest(context.Background()), http.StatusCreated).
+			SetError(&errorBody{}).
+			Post(client.GetPath("failed"))
+
+		var statusErr *StatusError
+		require.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+		assert.Equal(t, &errorBody{Message: "boom"}, statusErr.Body)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode())
+	})
+
+	t.Run("unexpected success status with result", func(t *testing.T) {
+		_, err := ExpectStatus(client.NewRequest(context.Background()), http.StatusOK).
+			SetResult(&errorBody{}).
+			Post(client.GetPath("created"))
+
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusCreated, statusErr.StatusCode)
+		assert.Equal(t, &errorBody{Message: "created"}, statusErr.Body)
+	})
+
+	t.Run("unexpected success status without result", func(t *testing.T) {
+		_, err := ExpectStatus(client.NewRequest(context.Background()), http.StatusOK).
+			Post(client.GetPath("created"))
+
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, `{"message":"created"}`, statusErr.Body)
+	})
+
+	t.Run("no expectation", func(t *testing.T) {
+		_, err := client.NewRequest(context.Background()).Post(client.GetPath("failed"))
+
+		require.NoError(t, err)
+	})
+}
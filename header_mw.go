@@ -0,0 +1,196 @@
+package httpz
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+	"resty.dev/v3"
+)
+
+// ErrMissingResponseHeader is returned from the verb call when a response omits
+// a header required by [WithRequiredResponseHeaders].
+var ErrMissingResponseHeader = errors.New("httpz: missing response header")
+
+// ErrSchemaVersionMismatch is returned from the verb call when the response
+// schema version differs from the [WithExpectedSchemaVersion] one in strict
+// mode, see [WithSchemaVersionStrict].
+var ErrSchemaVersionMismatch = errors.New("httpz: response schema version mismatch")
+
+// setNonceHeader sets a fresh cryptographically-random nonce on every attempt,
+// so retries of the same request carry different nonces.
+func setNonceHeader(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.nonceHeader == "" {
+			return nil
+		}
+
+		req.Header.Set(cfg.nonceHeader, rand.Text())
+
+		return nil
+	}
+}
+
+// setRequestID sets the request ID of [WithOutgoingRequestID], generated once
+// per request and stored in its context, so the retries, the span and the logs
+// all carry the same one.
+func setRequestID(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.requestIDHeader == "" {
+			return nil
+		}
+
+		ctx := req.Context()
+		id, ok := RequestIDFromContext(ctx)
+		if !ok {
+			id = rand.Text()
+			req.SetContext(context.WithValue(ctx, requestIDKey, id))
+		}
+		req.Header.Set(cfg.requestIDHeader, id)
+
+		return nil
+	}
+}
+
+// setHeaderFuncs sets the headers of [WithPerRequestHeaderFunc] on every
+// attempt.
+func setHeaderFuncs(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		for name, fn := range cfg.headerFuncs {
+			req.Header.Set(name, fn())
+		}
+
+		return nil
+	}
+}
+
+// setForwardedFor forwards the client IP stored in the request context.
+func setForwardedFor(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.forwardedForHeader == "" {
+			return nil
+		}
+
+		if ip, ok := ClientIPFromContext(req.Context()); ok {
+			req.Header.Set(cfg.forwardedForHeader, ip)
+		}
+
+		return nil
+	}
+}
+
+// setTraceIDHeader sets the trace ID of the request span, so the server can log
+// it even when it doesn't support trace context propagation.
+func setTraceIDHeader(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.traceIDHeader == "" {
+			return nil
+		}
+
+		if sc := trace.SpanContextFromContext(req.Context()); sc.HasTraceID() {
+			req.Header.Set(cfg.traceIDHeader, sc.TraceID().String())
+		}
+
+		return nil
+	}
+}
+
+// setPathHeaders sets the headers of the path the request targets, looked up by
+// its path template, unless already set on the request.
+func setPathHeaders(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if len(cfg.pathHeaders) == 0 {
+			return nil
+		}
+
+		name, ok := lookupPathName(cfg, req.URL)
+		if !ok {
+			return nil
+		}
+		for k, v := range cfg.pathHeaders[name] {
+			if req.Header.Get(k) == "" {
+				req.Header.Set(k, v)
+			}
+		}
+
+		return nil
+	}
+}
+
+// setPinnedHeaders sets the pinned headers on every attempt, after the
+// per-request headers have been set, so they always win.
+func setPinnedHeaders(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		for k, v := range cfg.pinnedHeaders {
+			req.Header.Set(k, v)
+		}
+
+		return nil
+	}
+}
+
+// setDeadlineHeader sets the remaining time of the request context deadline in
+// milliseconds, so the server can align its own timeout.
+func setDeadlineHeader(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.deadlineHeader == "" {
+			return nil
+		}
+
+		if deadline, ok := req.Context().Deadline(); ok {
+			remaining := max(time.Until(deadline).Milliseconds(), 0)
+			req.Header.Set(cfg.deadlineHeader, strconv.FormatInt(remaining, 10))
+		}
+
+		return nil
+	}
+}
+
+// checkRequiredHeaders fails a response missing one of the required headers.
+func checkRequiredHeaders(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		for _, h := range cfg.requiredResHeaders {
+			if res.Header().Get(h) == "" {
+				return fmt.Errorf("%w: %s", ErrMissingResponseHeader, h)
+			}
+		}
+
+		return nil
+	}
+}
+
+// checkSchemaVersion logs a warning, or fails the request in strict mode, when
+// the response schema version header isn't the [WithExpectedSchemaVersion] one,
+// e.g. when a request is routed to an old backend.
+func checkSchemaVersion(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		if cfg.schemaVersionHeader == "" || res.RawResponse == nil {
+			return nil
+		}
+
+		version := res.Header().Get(cfg.schemaVersionHeader)
+		if version == cfg.schemaVersion {
+			return nil
+		}
+		if cfg.schemaVersionStrict {
+			return fmt.Errorf("%w: %s %q, expected %q",
+				ErrSchemaVersionMismatch, cfg.schemaVersionHeader, version, cfg.schemaVersion)
+		}
+
+		ctx := res.Request.Context()
+		requestLogger(ctx, cfg).WarnContext(ctx, "[HTTPZ] response schema version mismatch",
+			slog.String(string(semconv.URLFullKey), res.Request.URL),
+			slog.String("header", cfg.schemaVersionHeader),
+			slog.String("version", version),
+			slog.String("expected_version", cfg.schemaVersion),
+		)
+
+		return nil
+	}
+}
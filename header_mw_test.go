@@ -0,0 +1,390 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNonceHeader(t *testing.T) {
+	var nonces []string
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/nonce",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			nonces = append(nonces, r.Header.Get("X-Nonce"))
+			if len(nonces) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"nonce": "/test/nonce"}),
+		WithNonceHeader("X-Nonce"),
+	)
+	client.SetRetryCount(2)
+	client.SetRetryWaitTime(1 * time.Millisecond)
+	client.SetRetryMaxWaitTime(1 * time.Millisecond)
+
+	res, err := client.NewRequest(context.Background()).Get(client.GetPath("nonce"))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	require.Len(t, nonces, 3)
+	for _, n := range nonces {
+		assert.NotEmpty(t, n)
+	}
+	assert.NotEqual(t, nonces[0], nonces[1])
+	assert.NotEqual(t, nonces[1], nonces[2])
+	assert.NotEqual(t, nonces[0], nonces[2])
+}
+
+func TestPerRequestHeaderFunc(t *testing.T) {
+	var got []string
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/cache-bust",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, r.Header.Get("X-Cache-Bust"))
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	n := 0
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"cacheBust": "/test/cache-bust"}),
+		WithPerRequestHeaderFunc("X-Cache-Bust", func() string {
+			n++
+			return strconv.Itoa(n)
+		}),
+	)
+
+	for range 2 {
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("cacheBust"))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"1", "2"}, got)
+}
+
+func TestForwardedForFromContext(t *testing.T) {
+	var gotXFF, gotCustom string
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/forwarded",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			gotXFF = r.Header.Get("X-Forwarded-For")
+			gotCustom = r.Header.Get("X-Real-Ip")
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	paths := map[string]string{"forwarded": "/test/forwarded"}
+
+	t.Run("default header", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithForwardedForFromContext(""),
+		)
+		ctx := WithClientIP(context.Background(), "203.0.113.7")
+
+		res, err := client.NewRequest(ctx).Get(client.GetPath("forwarded"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "203.0.113.7", gotXFF)
+	})
+
+	t.Run("custom header", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithForwardedForFromContext("X-Real-Ip"),
+		)
+		ctx := WithClientIP(context.Background(), "203.0.113.8")
+
+		res, err := client.NewRequest(ctx).Get(client.GetPath("forwarded"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "203.0.113.8", gotCustom)
+		assert.Empty(t, gotXFF)
+	})
+
+	t.Run("no ip in context", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithForwardedForFromContext(""),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("forwarded"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Empty(t, gotXFF)
+	})
+}
+
+func TestTraceIDHeader(t *testing.T) {
+	var gotTraceID string
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/trace-id",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			gotTraceID = r.Header.Get("X-Trace-Id")
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	paths := map[string]string{"traceID": "/test/trace-id"}
+
+	t.Run("header matches span trace id", func(t *testing.T) {
+		rec := tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithTracer(tp),
+			WithOtelMWEnabled(true),
+			WithTraceIDHeader(""),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("traceID"))
+
+		require.NoError(t, err)
+		spans := rec.Ended()
+		require.Len(t, spans, 1)
+		wantTraceID := spans[0].SpanContext().TraceID().String()
+		assert.Equal(t, wantTraceID, gotTraceID)
+		assert.Equal(t, wantTraceID, TraceID(res))
+	})
+
+	t.Run("not traced", func(t *testing.T) {
+		gotTraceID = ""
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithTraceIDHeader(""),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("traceID"))
+
+		require.NoError(t, err)
+		assert.Empty(t, gotTraceID)
+		assert.Empty(t, TraceID(res))
+	})
+}
+
+func TestPinnedHeaders(t *testing.T) {
+	var gotAPIKey, gotBase string
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/pinned",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			gotAPIKey = r.Header.Get("X-Api-Key")
+			gotBase = r.Header.Get("X-Base")
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"pinned": "/test/pinned"}),
+		WithBaseHeaders(map[string]string{"X-Base": "base", "X-Api-Key": "base-key"}),
+		WithPinnedHeaders(map[string]string{"X-Api-Key": "pinned-key"}),
+	)
+
+	_, err := client.NewRequest(context.Background()).
+		SetHeader("X-Api-Key", "request-key").
+		SetHeader("X-Base", "request").
+		Get(client.GetPath("pinned"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "pinned-key", gotAPIKey)
+	assert.Equal(t, "request", gotBase)
+}
+
+func TestDeadlinePropagationHeader(t *testing.T) {
+	var gotTimeout string
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/deadline",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			gotTimeout = r.Header.Get("X-Request-Timeout-Ms")
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"deadline": "/test/deadline"}),
+		WithDeadlinePropagationHeader(""),
+	)
+
+	t.Run("context with deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, err := client.NewRequest(ctx).Get(client.GetPath("deadline"))
+
+		require.NoError(t, err)
+		ms, err := strconv.Atoi(gotTimeout)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, ms, 5000)
+		assert.Greater(t, ms, 4000)
+	})
+
+	t.Run("context without deadline", func(t *testing.T) {
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("deadline"))
+
+		require.NoError(t, err)
+		assert.Empty(t, gotTimeout)
+	})
+}
+
+func TestRequiredResponseHeaders(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/versioned",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Api-Version", "2")
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/unversioned",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+	)
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{
+			"versioned":   "/test/versioned",
+			"unversioned": "/test/unversioned",
+		}),
+		WithRequiredResponseHeaders([]string{"X-Api-Version"}),
+	)
+
+	t.Run("header present", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("versioned"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+	})
+
+	t.Run("header missing", func(t *testing.T) {
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("unversioned"))
+
+		require.ErrorIs(t, err, ErrMissingResponseHeader)
+		assert.ErrorContains(t, err, "X-Api-Version")
+	})
+}
+
+func TestPathHeaders(t *testing.T) {
+	gotAccept := map[string]string{}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		gotAccept[r.URL.Path] = r.Header.Get("Accept")
+		w.WriteHeader(http.StatusOK)
+	}
+	server := startTestServer(t,
+		testHandler{method: http.MethodGet, path: "/test/csv", handlerFunc: handler},
+		testHandler{method: http.MethodGet, path: "/test/json", handlerFunc: handler},
+	)
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{
+			"csv":  "/test/csv",
+			"json": "/test/json",
+		}),
+		WithBaseHeaders(map[string]string{"Accept": "application/json"}),
+		WithPathHeaders(map[string]map[string]string{
+			"csv": {"Accept": "text/csv"},
+		}),
+	)
+
+	t.Run("applies to its path only", func(t *testing.T) {
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("csv"))
+		require.NoError(t, err)
+		_, err = client.NewRequest(context.Background()).Get(client.GetPath("json"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "text/csv", gotAccept["/test/csv"])
+		assert.Equal(t, "application/json", gotAccept["/test/json"])
+	})
+
+	t.Run("per-request header wins", func(t *testing.T) {
+		_, err := client.NewRequest(context.Background()).
+			SetHeader("Accept", "text/plain").
+			Get(client.GetPath("csv"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "text/plain", gotAccept["/test/csv"])
+	})
+}
+
+func TestExpectedSchemaVersion(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/schema/v2",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Schema-Version", "2")
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/schema/v1",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Schema-Version", "1")
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+	)
+
+	t.Run("matching version", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		client := NewClient("test-client", server.URL,
+			WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+			WithExpectedSchemaVersion("X-Schema-Version", "2"),
+			WithSchemaVersionStrict(true),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get("/test/schema/v2")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Empty(t, b.String())
+	})
+
+	t.Run("mismatch logs a warning", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		client := NewClient("test-client", server.URL,
+			WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+			WithExpectedSchemaVersion("X-Schema-Version", "2"),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get("/test/schema/v1")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		logs := b.String()
+		assert.Contains(t, logs, `"level":"WARN","msg":"[HTTPZ] response schema version mismatch"`)
+		assert.Contains(t, logs, `"version":"1","expected_version":"2"`)
+	})
+
+	t.Run("mismatch errors when strict", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithExpectedSchemaVersion("X-Schema-Version", "2"),
+			WithSchemaVersionStrict(true),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get("/test/schema/v1")
+
+		require.ErrorIs(t, err, ErrSchemaVersionMismatch)
+		assert.ErrorContains(t, err, `X-Schema-Version "1", expected "2"`)
+	})
+}
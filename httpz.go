@@ -3,11 +3,10 @@ package httpz
 import (
 	"context"
 	"fmt"
-	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 
-	"github.com/goccy/go-json"
 	"go.opentelemetry.io/otel"
 	"resty.dev/v3"
 )
@@ -16,61 +15,370 @@ type Client struct {
 	resty.Client
 	name    string
 	version string
-	paths   map[string]string
+	cfg     *config
+	// startupErr is the error of the [WithStartupProbe] request.
+	startupErr error
+	async      *asyncPool
 }
 
 func NewClient(clientName, baseURL string, opts ...option) *Client {
+	client := newClient(clientName, baseURL, opts...)
+	if client.startupErr != nil {
+		client.cfg.logger.Error("[HTTPZ] startup probe failed",
+			slog.String("client", clientName),
+			slog.String("error", client.startupErr.Error()),
+		)
+	}
+
+	return client
+}
+
+// NewClientE is [NewClient] returning the error of the [WithStartupProbe]
+// request instead of logging it, the client is closed and nil is returned when
+// the probe fails.
+func NewClientE(clientName, baseURL string, opts ...option) (*Client, error) {
+	client := newClient(clientName, baseURL, opts...)
+	if err := client.startupErr; err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func newClient(clientName, baseURL string, opts ...option) *Client {
 	cfg := config{}
 	for _, opt := range opts {
 		opt(&cfg)
 	}
+	cfg.setDefaults(clientName)
+	if cfg.replayMaxSize > 0 {
+		cfg.captureRawResponse = true
+		if cfg.maxResponseBodySize == 0 || cfg.replayMaxSize < cfg.maxResponseBodySize {
+			cfg.maxResponseBodySize = cfg.replayMaxSize
+		}
+	}
+	if cfg.resNullFields {
+		cfg.captureRawResponse = true
+	}
+	if len(cfg.baseURLs) > 0 {
+		cfg.balancer = newBalancer(&cfg)
+	}
+	if !cfg.circuitBreakerEnabled {
+		cfg.circuitBreaker = nil
+		cfg.cbWarmup = nil
+	}
+	applyTransportConfig(&cfg)
+	applyTransportWrappers(&cfg)
+	if cfg.resIdleTimeout > 0 {
+		cfg.transport = &idleTimeoutTransport{next: cfg.transport, timeout: cfg.resIdleTimeout}
+	}
+	if cfg.sniffCompression {
+		cfg.transport = &compressionSniffTransport{next: cfg.transport}
+	}
+	var reauth *reauthTransport
+	if cfg.reauth != nil {
+		reauth = &reauthTransport{next: cfg.transport, refresh: cfg.reauth}
+		cfg.transport = reauth
+	}
+	if cfg.cache != nil {
+		maxBodySize := int64(defaultCacheMaxBodySize)
+		if cfg.maxResponseBodySize > 0 {
+			maxBodySize = cfg.maxResponseBodySize
+		}
+		cfg.transport = &cacheTransport{next: cfg.transport, cache: cfg.cache, maxBodySize: maxBodySize}
+	}
+	if cfg.onResponseBytes != nil {
+		limit := int64(defaultOnResponseBytesLimit)
+		if cfg.maxResponseBodySize > 0 {
+			limit = cfg.maxResponseBodySize
+		}
+		cfg.transport = &onResponseBytesTransport{next: cfg.transport, fn: cfg.onResponseBytes, limit: limit}
+	}
+	if cfg.mapTimeoutErr {
+		cfg.transport = &timeoutTransport{next: cfg.transport}
+	}
+
+	httpClient := &http.Client{}
+	if cfg.httpClient != nil {
+		// a copy, so the transport wrappers don't leak into the injected client
+		c := *cfg.httpClient
+		httpClient = &c
+	}
+	httpClient.Transport = cfg.transport
+	restyClient := resty.NewWithClient(httpClient)
+	restyClient.
+		// right after the body serialization, which stays the last request
+		// middleware as the others are inserted before it
+		SetRequestMiddlewares(chainRequest(
+			resty.PrepareRequestMiddleware,
+			setReaderContentLength(&cfg),
+		)).
+		SetResponseMiddlewares(
+			traceDecode(&cfg, drainTrailers(limitErrorBody(&cfg, resty.AutoParseResponseMiddleware))),
+			resty.SaveToFileResponseMiddleware,
+		).
+		SetBaseURL(baseURL).
+		SetCircuitBreaker(cfg.circuitBreaker).
+		SetAllowNonIdempotentRetry(cfg.retryNonIdempotent).
+		SetResponseBodyLimit(cfg.maxResponseBodySize).
+		SetResponseBodyUnlimitedReads(cfg.captureRawResponse).
+		AddContentTypeDecoder("application/json", jsonDecoder(&cfg)).
+		SetHeaders(cfg.baseHeaders).
+		SetLogger(logger{cfg.logger}).
+		AddRequestMiddleware(validateRequestBody(&cfg)).
+		AddRequestMiddleware(throttleWarmup(&cfg)).
+		AddRequestMiddleware(setTimeoutContext(&cfg)).
+		AddRequestMiddleware(setRequestTimeout()).
+		AddRequestMiddleware(setPathTimeout(&cfg)).
+		AddRequestMiddleware(setMethodTimeout(&cfg)).
+		AddRequestMiddleware(startRetryBudget(&cfg)).
+		AddRequestMiddleware(depositRetryTokens(&cfg)).
+		AddRequestMiddleware(bufferReaderBody(&cfg)).
+		AddRequestMiddleware(detectContentType(&cfg)).
+		AddRequestMiddleware(assumeJSON(&cfg)).
+		AddRequestMiddleware(overrideResponseContentType(&cfg)).
+		AddRequestMiddleware(setPathHeaders(&cfg)).
+		AddRequestMiddleware(setPinnedHeaders(&cfg)).
+		AddRequestMiddleware(setNonceHeader(&cfg)).
+		AddRequestMiddleware(setRequestID(&cfg)).
+		AddRequestMiddleware(setHeaderFuncs(&cfg)).
+		AddRequestMiddleware(setForwardedFor(&cfg)).
+		AddRequestMiddleware(setDeadlineHeader(&cfg)).
+		AddRequestMiddleware(storePathName(&cfg)).
+		AddRequestMiddleware(keepResponseKeysBody(&cfg)).
+		AddRequestMiddleware(pickBaseURL(&cfg)).
+		AddResponseMiddleware(allowTruncatedErrorBody()).
+		AddResponseMiddleware(checkResponseContentType(&cfg)).
+		AddResponseMiddleware(checkRequiredHeaders(&cfg)).
+		AddResponseMiddleware(checkSchemaVersion(&cfg)).
+		AddResponseMiddleware(checkExpectedStatus()).
+		AddResponseMiddleware(runStatusHandlers(&cfg)).
+		AddResponseMiddleware(recordLatency(&cfg)).
+		AddResponseMiddleware(checkResponseKeys(&cfg)).
+		AddResponseMiddleware(reportBaseURLResponse(&cfg)).
+		AddResponseMiddleware(storeResponseBody(&cfg)).
+		AddResponseMiddleware(storeResponseDuration(&cfg)).
+		AddRetryHooks(endInflightRetry(&cfg)).
+		AddRetryHooks(addAttemptError(&cfg)).
+		AddRetryHooks(reportBaseURLRetry(&cfg)).
+		OnSuccess(endInflightSuccess(&cfg)).
+		OnError(tripWarmup(&cfg)).
+		OnError(countCircuitBreakerDenial(&cfg)).
+		OnError(reportBaseURLError(&cfg)).
+		OnError(endInflightError(&cfg)).
+		OnInvalid(endInflightError(&cfg)).
+		OnPanic(endInflightError(&cfg))
+	addInterceptors(&cfg, restyClient)
+	restyClient.AddResponseMiddleware(mapTimeoutResponse(&cfg))
+
+	if cfg.maxRetryElapsed > 0 || cfg.retryTokens != nil {
+		restyClient.
+			SetRetryDefaultConditions(false).
+			AddRetryConditions(retryWithinBudget(&cfg))
+	}
+
+	if len(cfg.redirectPolicies) > 0 {
+		restyClient.SetRedirectPolicy(cfg.redirectPolicies...)
+	}
+
+	if cfg.basicAuth != nil {
+		restyClient.SetBasicAuth(cfg.basicAuth.username, cfg.basicAuth.password)
+	}
+
+	if cfg.authToken != "" {
+		restyClient.SetAuthToken(cfg.authToken)
+	}
+	if cfg.authScheme != "" {
+		restyClient.SetAuthScheme(cfg.authScheme)
+	}
+
+	client := &Client{
+		Client:  *restyClient,
+		name:    clientName,
+		version: cfg.serviceVersion,
+		cfg:     &cfg,
+		async:   newAsyncPool(cfg.asyncWorkers),
+	}
+	if reauth != nil {
+		reauth.client = client
+	}
+	if cfg.startupProbe != nil {
+		client.startupErr = client.probe(cfg.startupProbe)
+	}
+
+	return client
+}
+
+// setDefaults fills the options left unset, clientName being the default peer
+// service.
+func (cfg *config) setDefaults(clientName string) {
+	if cfg.httpClient != nil {
+		cfg.transport = cfg.httpClient.Transport
+	}
 	if cfg.transport == nil {
 		cfg.transport = http.DefaultTransport
 	}
 	if cfg.paths == nil {
 		cfg.paths = make(map[string]string)
 	}
+	cfg.indexPathNames()
+	cfg.resolvedPathNames = make(map[string]string)
 	if cfg.logger == nil {
 		cfg.logger = slog.Default()
 	}
 	if cfg.tracer == nil {
+		if cfg.otelMWEnabled {
+			cfg.logger.Info("[HTTPZ] otel middleware enabled without a tracer provider, using the global one",
+				slog.String("client", clientName),
+			)
+		}
 		cfg.tracer = otel.GetTracerProvider()
 	}
+	if cfg.peerService == "" {
+		cfg.peerService = clientName
+	}
 	if cfg.propagator == nil {
 		cfg.propagator = otel.GetTextMapPropagator()
 	}
-	if !cfg.circuitBreakerEnabled {
-		cfg.circuitBreaker = nil
+	if cfg.meter == nil {
+		cfg.meter = otel.GetMeterProvider()
 	}
-
-	restyClient := resty.NewWithClient(&http.Client{
-		Transport: cfg.transport,
-	})
-	restyClient.
-		SetBaseURL(baseURL).
-		SetCircuitBreaker(cfg.circuitBreaker).
-		AddContentTypeDecoder("application/json", func(r io.Reader, v any) error {
-			return json.NewDecoder(r).Decode(v)
-		}).
-		SetHeaders(cfg.baseHeaders).
-		SetLogger(logger{cfg.logger}).
-		AddRequestMiddleware(startTrace(&cfg)).
-		AddRequestMiddleware(logRequest(&cfg)).
-		AddResponseMiddleware(logResponse(&cfg)).
-		AddResponseMiddleware(endTraceSuccess(&cfg)).
-		OnError(endTraceError(&cfg)).
-		OnPanic(endTraceError(&cfg))
-
-	return &Client{
-		Client:  *restyClient,
-		name:    clientName,
-		version: cfg.serviceVersion,
-		paths:   cfg.paths,
+	if cfg.metricsMWEnabled {
+		cfg.instruments = newInstruments(cfg)
+	}
+	if cfg.asyncWorkers <= 0 {
+		cfg.asyncWorkers = 10
 	}
 }
 
+// GetPath returns the path template registered with pathName.
+//
+// Leading slashes are collapsed into one, so joining it with the base URL
+// (whose trailing slashes are trimmed by resty) never produces "//", which
+// strict routers treat as a different route.
+//
+// It's resolved by the [WithPathResolver] resolver, if any, with
+// [context.Background], see [Client.GetPathContext].
 func (c *Client) GetPath(pathName string) string {
-	return c.paths[pathName]
+	return c.GetPathContext(context.Background(), pathName)
+}
+
+// GetPathContext is [Client.GetPath] resolving pathName with the
+// [WithPathResolver] resolver for ctx first, falling back to the registered
+// template when it returns "".
+func (c *Client) GetPathContext(ctx context.Context, pathName string) string {
+	if c.cfg.pathResolver != nil {
+		if p := c.cfg.pathResolver(ctx, pathName); p != "" {
+			p = normalizePath(p)
+			c.cfg.storeResolvedPathName(p, pathName)
+			return p
+		}
+	}
+
+	c.cfg.pathsMu.RLock()
+	defer c.cfg.pathsMu.RUnlock()
+	return normalizePath(c.cfg.paths[pathName])
+}
+
+// AddPath registers the path template under pathName, replacing the previous
+// one, e.g. when a feature flag switches an endpoint. It's safe to call
+// concurrently with requests and [Client.GetPath].
+func (c *Client) AddPath(pathName, template string) {
+	c.cfg.pathsMu.Lock()
+	defer c.cfg.pathsMu.Unlock()
+	c.cfg.paths[pathName] = template
+	c.cfg.indexPathNames()
+}
+
+// RemovePath unregisters the path template of pathName, [Client.GetPath] then
+// returns "".
+func (c *Client) RemovePath(pathName string) {
+	c.cfg.pathsMu.Lock()
+	defer c.cfg.pathsMu.Unlock()
+	delete(c.cfg.paths, pathName)
+	c.cfg.indexPathNames()
+}
+
+// maxResolvedPathNames bounds the paths returned by the [WithPathResolver]
+// resolver remembered for [lookupPathName].
+const maxResolvedPathNames = 1000
+
+// indexPathNames rebuilds the template to name index of [lookupPathName], a
+// template shared by several names maps to the first one in sorted order. The
+// caller holds pathsMu for writing, or owns cfg.
+func (cfg *config) indexPathNames() {
+	cfg.pathNames = make(map[string]string, len(cfg.paths))
+	for name, p := range cfg.paths {
+		p = normalizePath(p)
+		if cur, ok := cfg.pathNames[p]; !ok || name < cur {
+			cfg.pathNames[p] = name
+		}
+	}
+}
+
+// storeResolvedPathName remembers the name of a path returned by the
+// [WithPathResolver] resolver, which may match no template, up to
+// [maxResolvedPathNames] paths.
+func (cfg *config) storeResolvedPathName(p, name string) {
+	cfg.pathsMu.RLock()
+	_, ok := cfg.resolvedPathNames[p]
+	cfg.pathsMu.RUnlock()
+	if ok {
+		return
+	}
+
+	cfg.pathsMu.Lock()
+	defer cfg.pathsMu.Unlock()
+	if len(cfg.resolvedPathNames) < maxResolvedPathNames {
+		cfg.resolvedPathNames[p] = name
+	}
+}
+
+// lookupPathName returns the name of the path template reqURL targets, or of
+// the path resolved by [Client.GetPathContext], reqURL being the URL given to
+// the verb call.
+func lookupPathName(cfg *config, reqURL string) (string, bool) {
+	reqPath, _, _ := strings.Cut(reqURL, "?")
+	cfg.pathsMu.RLock()
+	defer cfg.pathsMu.RUnlock()
+	if name, ok := cfg.pathNames[reqPath]; ok {
+		return name, true
+	}
+	name, ok := cfg.resolvedPathNames[reqPath]
+	return name, ok
+}
+
+type pathNameCtxKey struct{}
+
+// storePathName stores the name of the path the request targets in its
+// context for the features needing it on the response, which only knows the
+// URL with the path params replaced, see [requestPathName].
+func storePathName(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.latency == nil && len(cfg.expectedResKeys) == 0 {
+			return nil
+		}
+
+		if name, ok := lookupPathName(cfg, req.URL); ok {
+			req.SetContext(context.WithValue(req.Context(), pathNameCtxKey{}, name))
+		}
+
+		return nil
+	}
+}
+
+// requestPathName returns the path name stored by [storePathName].
+func requestPathName(req *resty.Request) (string, bool) {
+	name, ok := req.Context().Value(pathNameCtxKey{}).(string)
+	return name, ok
+}
+
+func normalizePath(p string) string {
+	if strings.HasPrefix(p, "/") {
+		p = "/" + strings.TrimLeft(p, "/")
+	}
+	return p
 }
 
 // NewRequest returns *[resty.Request] from given context.
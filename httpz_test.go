@@ -1,16 +1,25 @@
 package httpz
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
 	"resty.dev/v3"
 )
 
@@ -151,6 +160,95 @@ func TestGetNonExistPath(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, res.StatusCode())
 }
 
+func TestAddRemovePath(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/v2/users",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	paths := map[string]string{"listUsers": "/v1/users"}
+	client := NewClient("test-client", server.URL,
+		WithPaths(paths),
+		WithPathHeaders(map[string]map[string]string{"listUsers": {"X-Version": "2"}}),
+	)
+
+	client.AddPath("listUsers", "/v2/users")
+
+	res, err := client.NewRequest(context.Background()).Get(client.GetPath("listUsers"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Equal(t, "2", res.Request.Header.Get("X-Version"))
+	assert.Equal(t, "/v1/users", paths["listUsers"], "WithPaths map modified")
+
+	client.RemovePath("listUsers")
+
+	assert.Empty(t, client.GetPath("listUsers"))
+}
+
+func TestAddPathConcurrent(t *testing.T) {
+	client := NewClient("test-client", "http://localhost",
+		WithPaths(map[string]string{"getUser": "/users/{id}"}),
+	)
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("path%d", i)
+			client.AddPath(name, "/"+name)
+			client.RemovePath(name)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "/users/{id}", client.GetPath("getUser"))
+			lookupPathName(client.cfg, "/users/{id}")
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPathResolver(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/checkout",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("control"))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/checkout-v2",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("experiment"))
+			},
+		},
+	)
+	type variantKey struct{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"checkout": "/checkout"}),
+		WithPathResolver(func(ctx context.Context, name string) string {
+			if name == "checkout" && ctx.Value(variantKey{}) == "B" {
+				return "/checkout-v2"
+			}
+			return ""
+		}),
+	)
+
+	for variant, want := range map[string]string{"A": "control", "B": "experiment"} {
+		ctx := context.WithValue(context.Background(), variantKey{}, variant)
+
+		res, err := client.NewRequest(ctx).Get(client.GetPathContext(ctx, "checkout"))
+
+		require.NoError(t, err)
+		assert.Equal(t, want, res.String(), "variant %s", variant)
+	}
+	assert.Equal(t, "/checkout", client.GetPath("checkout"))
+}
+
 func TestSetClientAndRequestHeaders(t *testing.T) {
 	type testGetRes struct {
 		Code int `json:"code"`
@@ -407,3 +505,224 @@ func TestClientCircuitBreaker(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.StatusCode())
 	assert.NotNil(t, res)
 }
+
+func TestRetryIdempotentOnly(t *testing.T) {
+	attempts := 0
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/retry/idempotent",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	})
+	newClient := func(opts ...option) *Client {
+		opts = append(opts, WithPaths(map[string]string{"retry": "/test/retry/idempotent"}))
+		client := NewClient("test-client", server.URL, opts...)
+		client.SetRetryCount(2)
+		client.SetRetryWaitTime(1 * time.Millisecond)
+		client.SetRetryMaxWaitTime(1 * time.Millisecond)
+		return client
+	}
+
+	t.Run("default retries get", func(t *testing.T) {
+		attempts = 0
+		client := newClient()
+
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("retry"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode())
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("default does not retry post", func(t *testing.T) {
+		attempts = 0
+		client := newClient()
+
+		res, err := client.NewRequest(context.Background()).Post(client.GetPath("retry"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode())
+		assert.Equal(t, 1, attempts)
+	})
+
+	tests := []struct {
+		name         string
+		opts         []option
+		wantAttempts int
+	}{
+		{name: "disabled retries post", opts: []option{WithRetryIdempotentOnly(false)}, wantAttempts: 3},
+		{name: "enabled does not retry post", opts: []option{WithRetryIdempotentOnly(true)}, wantAttempts: 1},
+		{
+			name:         "enabled after disabled does not retry post",
+			opts:         []option{WithRetryIdempotentOnly(false), WithRetryIdempotentOnly(true)},
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts = 0
+			client := newClient(tt.opts...)
+
+			res, err := client.NewRequest(context.Background()).Post(client.GetPath("retry"))
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode())
+			assert.Equal(t, tt.wantAttempts, attempts)
+		})
+	}
+}
+
+func TestClientBasicAuth(t *testing.T) {
+	var gotUser, gotPass string
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/auth/basic",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+
+			assert.True(t, ok)
+
+			gotUser, gotPass = user, pass
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"testBasicAuth": "/test/auth/basic"}),
+		WithBasicAuth("client-user", "client-pass"),
+	)
+
+	t.Run("client-wide basic auth", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).
+			Get(client.GetPath("testBasicAuth"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "client-user", gotUser)
+		assert.Equal(t, "client-pass", gotPass)
+	})
+
+	t.Run("per-request override", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).
+			SetBasicAuth("req-user", "req-pass").
+			Get(client.GetPath("testBasicAuth"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "req-user", gotUser)
+		assert.Equal(t, "req-pass", gotPass)
+	})
+}
+
+func TestClientAuthToken(t *testing.T) {
+	var gotAuth string
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/auth/token",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			gotAuth = r.Header.Get("Authorization")
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	paths := map[string]string{"testTokenAuth": "/test/auth/token"}
+
+	t.Run("client-wide bearer token", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithAuthToken("client-token"),
+		)
+
+		res, err := client.NewRequest(context.Background()).
+			Get(client.GetPath("testTokenAuth"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "Bearer client-token", gotAuth)
+	})
+
+	t.Run("client-wide custom scheme", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithAuthToken("client-token"),
+			WithAuthScheme("Token"),
+		)
+
+		res, err := client.NewRequest(context.Background()).
+			Get(client.GetPath("testTokenAuth"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "Token client-token", gotAuth)
+	})
+
+	t.Run("per-request override", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithAuthToken("client-token"),
+		)
+
+		res, err := client.NewRequest(context.Background()).
+			SetAuthToken("req-token").
+			Get(client.GetPath("testTokenAuth"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "Bearer req-token", gotAuth)
+	})
+}
+
+func TestBaseURLPathJoin(t *testing.T) {
+	var gotPath string
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	client := NewClient("test-client", server.URL+"/", WithPaths(map[string]string{
+		"users":       "/users",
+		"usersDouble": "//users",
+		"usersNoLead": "users",
+	}))
+
+	for _, pathName := range []string{"users", "usersDouble", "usersNoLead"} {
+		t.Run(pathName, func(t *testing.T) {
+			res, err := client.NewRequest(context.Background()).Get(client.GetPath(pathName))
+
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode())
+			assert.Equal(t, "/users", gotPath)
+			assert.Equal(t, server.URL+"/users", res.Request.URL)
+		})
+	}
+}
+
+func TestObservability(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/observability",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	b := &bytes.Buffer{}
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"observability": "/test/observability"}),
+		WithObservability(slog.New(slog.NewJSONHandler(b, nil)), tp, mp),
+	)
+
+	_, err := client.NewRequest(context.Background()).Get(client.GetPath("observability"))
+
+	require.NoError(t, err)
+	assert.Contains(t, b.String(), "[HTTPZ][OUTGOING REQUEST]")
+	assert.Contains(t, b.String(), "[HTTPZ][INCOMING RESPONSE]")
+	assert.Len(t, rec.Ended(), 1)
+	findMetric(t, reader, semconv.HTTPClientRequestDurationName)
+}
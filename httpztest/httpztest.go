@@ -0,0 +1,97 @@
+// Package httpztest provides helpers to test code built on httpz against an
+// [httptest.Server].
+package httpztest
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Content encodings supported by [EncodeHandler], the ones decompressed by
+// httpz.
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+// GzipHandler wraps h so its response body is gzip encoded, see
+// [EncodeHandler].
+func GzipHandler(h http.Handler) http.Handler {
+	return EncodeHandler(EncodingGzip, h)
+}
+
+// EncodeHandler wraps h so its response body is encoded with encoding, setting
+// "Content-Encoding" and dropping "Content-Length", e.g. to test the response
+// decompression of httpz:
+//
+//	server := httptest.NewServer(httpztest.GzipHandler(handler))
+//
+// It panics when encoding isn't [EncodingGzip] or [EncodingDeflate].
+func EncodeHandler(encoding string, h http.Handler) http.Handler {
+	if encoding != EncodingGzip && encoding != EncodingDeflate {
+		panic(fmt.Sprintf("httpztest: unsupported encoding %q", encoding))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ew := &encodeWriter{ResponseWriter: w, encoding: encoding}
+		defer ew.close()
+		h.ServeHTTP(ew, r)
+	})
+}
+
+type encodeWriter struct {
+	http.ResponseWriter
+	encoding    string
+	enc         io.WriteCloser
+	wroteHeader bool
+}
+
+func (w *encodeWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	// bodyless responses aren't encoded
+	if code != http.StatusNoContent && code != http.StatusNotModified {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.encoding)
+		h.Add("Vary", "Accept-Encoding")
+		if w.encoding == EncodingGzip {
+			w.enc = gzip.NewWriter(w.ResponseWriter)
+		} else {
+			w.enc, _ = flate.NewWriter(w.ResponseWriter, flate.DefaultCompression)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *encodeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.enc == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.enc.Write(b)
+}
+
+// Flush flushes the encoded bytes written so far to the client.
+func (w *encodeWriter) Flush() {
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *encodeWriter) close() {
+	if w.enc != nil {
+		_ = w.enc.Close()
+	}
+}
@@ -0,0 +1,82 @@
+package httpztest_test
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unlimited-budget-ecommerce/httpz"
+	"github.com/unlimited-budget-ecommerce/httpz/httpztest"
+)
+
+func TestEncodeHandler(t *testing.T) {
+	type testRes struct {
+		Name string `json:"name"`
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"name":"Alice"}`))
+	})
+
+	for _, encoding := range []string{httpztest.EncodingGzip, httpztest.EncodingDeflate} {
+		t.Run(encoding, func(t *testing.T) {
+			server := httptest.NewServer(httpztest.EncodeHandler(encoding, handler))
+			t.Cleanup(server.Close)
+			client := httpz.NewClient("test-client", server.URL)
+			result := &testRes{}
+
+			res, err := client.NewRequest(context.Background()).
+				SetResult(result).
+				Get("/")
+
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode())
+			assert.Equal(t, &testRes{Name: "Alice"}, result)
+		})
+	}
+}
+
+func TestEncodeHandlerEncodes(t *testing.T) {
+	tests := []struct {
+		encoding  string
+		newReader func(io.Reader) (io.Reader, error)
+	}{
+		{encoding: httpztest.EncodingGzip, newReader: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{encoding: httpztest.EncodingDeflate, newReader: func(r io.Reader) (io.Reader, error) { return flate.NewReader(r), nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			server := httptest.NewServer(httpztest.EncodeHandler(tt.encoding, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "5")
+				_, _ = w.Write([]byte("hello"))
+			})))
+			t.Cleanup(server.Close)
+			client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+			res, err := client.Get(server.URL)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.encoding, res.Header.Get("Content-Encoding"))
+			assert.NotEqual(t, "5", res.Header.Get("Content-Length"))
+			r, err := tt.newReader(res.Body)
+			require.NoError(t, err)
+			body, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(body))
+		})
+	}
+}
+
+func TestEncodeHandlerUnsupported(t *testing.T) {
+	assert.Panics(t, func() {
+		httpztest.EncodeHandler("br", http.NotFoundHandler())
+	})
+}
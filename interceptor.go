@@ -0,0 +1,148 @@
+package httpz
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+
+	"resty.dev/v3"
+)
+
+// Names of the built-in interceptors of [WithClientInterceptorChain], they
+// still run only when enabled, e.g. with [WithLogMWEnabled].
+const (
+	InterceptorLog     = "log"
+	InterceptorTrace   = "trace"
+	InterceptorMetrics = "metrics"
+)
+
+// Interceptor is a named pair of request and response middlewares of the chain
+// set by [WithClientInterceptorChain], either may be nil. An Interceptor
+// without middlewares refers to the built-in one of its Name, e.g.
+// Interceptor{Name: InterceptorLog}.
+type Interceptor struct {
+	Name     string
+	Request  resty.RequestMiddleware
+	Response resty.ResponseMiddleware
+
+	// onError is the error and panic hook of a built-in interceptor, which
+	// must only run when the interceptor is in the chain.
+	onError resty.ErrorHook
+}
+
+// DefaultInterceptorChain returns the chain used when
+// [WithClientInterceptorChain] isn't set: trace, metrics then log. A request
+// goes through trace, metrics then log, and its response through log, metrics
+// then trace, so the span and the in-flight count cover the logging too.
+func DefaultInterceptorChain() []Interceptor {
+	return []Interceptor{
+		{Name: InterceptorTrace},
+		{Name: InterceptorMetrics},
+		{Name: InterceptorLog},
+	}
+}
+
+// builtinInterceptor returns the built-in interceptor named name. The span
+// ending hook of the trace one is registered by [addInterceptors], so a chain
+// without it never ends a span it didn't start, the other hooks are registered
+// by [NewClient].
+func builtinInterceptor(cfg *config, name string) (Interceptor, bool) {
+	switch name {
+	case InterceptorTrace:
+		m := builtinMiddleware(cfg, name)
+		return Interceptor{Name: name, Request: m.Request, Response: m.Response, onError: m.OnError}, true
+	case InterceptorLog, InterceptorMetrics:
+		m := builtinMiddleware(cfg, name)
+		return Interceptor{Name: name, Request: m.Request, Response: m.Response}, true
+	default:
+		return Interceptor{}, false
+	}
+}
+
+func unlessSuppressedRequest(m resty.RequestMiddleware) resty.RequestMiddleware {
+	return func(c *resty.Client, req *resty.Request) error {
+		if telemetrySuppressed(req.Context()) {
+			return nil
+		}
+		return m(c, req)
+	}
+}
+
+func unlessSuppressedResponse(m resty.ResponseMiddleware) resty.ResponseMiddleware {
+	return func(c *resty.Client, res *resty.Response) error {
+		if telemetrySuppressed(res.Request.Context()) {
+			return nil
+		}
+		return m(c, res)
+	}
+}
+
+// chainRequest runs ms in order, stopping at the first error like resty does.
+func chainRequest(ms ...resty.RequestMiddleware) resty.RequestMiddleware {
+	return func(c *resty.Client, req *resty.Request) error {
+		for _, m := range ms {
+			if err := m(c, req); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// chainResponse runs all of ms in order, joining their errors like resty does.
+func chainResponse(ms ...resty.ResponseMiddleware) resty.ResponseMiddleware {
+	return func(c *resty.Client, res *resty.Response) error {
+		var errs []error
+		for _, m := range ms {
+			if err := m(c, res); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// addInterceptors adds the request middlewares of the interceptor chain in
+// order and its response middlewares in reverse order, so the first
+// interceptor sees the request first and the response last. The middlewares
+// of the user interceptors recover from panics.
+func addInterceptors(cfg *config, c *resty.Client) {
+	chain := cfg.interceptors
+	if chain == nil {
+		chain = DefaultInterceptorChain()
+	}
+
+	resolved := make([]Interceptor, 0, len(chain))
+	for _, ic := range chain {
+		if ic.Request == nil && ic.Response == nil {
+			builtin, ok := builtinInterceptor(cfg, ic.Name)
+			if !ok {
+				cfg.logger.Warn("[HTTPZ] unknown interceptor ignored", slog.String("interceptor", ic.Name))
+				continue
+			}
+			resolved = append(resolved, builtin)
+			continue
+		}
+		if ic.Request != nil {
+			ic.Request = recoverRequest(cfg, ic.Request)
+		}
+		if ic.Response != nil {
+			ic.Response = recoverResponse(cfg, ic.Response)
+		}
+		resolved = append(resolved, ic)
+	}
+
+	for _, ic := range resolved {
+		if ic.Request != nil {
+			c.AddRequestMiddleware(ic.Request)
+		}
+		if ic.onError != nil {
+			c.OnError(ic.onError).OnPanic(ic.onError)
+		}
+	}
+	for _, ic := range slices.Backward(resolved) {
+		if ic.Response != nil {
+			c.AddResponseMiddleware(ic.Response)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"resty.dev/v3"
+)
+
+func TestClientInterceptorChain(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/chain",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	var order []string
+	audit := Interceptor{
+		Name: "audit",
+		Request: func(_ *resty.Client, req *resty.Request) error {
+			order = append(order, "audit request")
+			req.SetHeader("X-Audit", "audited")
+			return nil
+		},
+		Response: func(_ *resty.Client, _ *resty.Response) error {
+			order = append(order, "audit response")
+			return nil
+		},
+	}
+	last := Interceptor{
+		Name: "last",
+		Request: func(_ *resty.Client, _ *resty.Request) error {
+			order = append(order, "last request")
+			return nil
+		},
+		Response: func(_ *resty.Client, _ *resty.Response) error {
+			order = append(order, "last response")
+			return nil
+		},
+	}
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"chain": "/test/chain"}),
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+		WithClientInterceptorChain(
+			Interceptor{Name: InterceptorTrace},
+			audit,
+			Interceptor{Name: InterceptorLog},
+			last,
+			Interceptor{Name: "unknown"},
+		),
+	)
+
+	_, err := client.NewRequest(context.Background()).Get(client.GetPath("chain"))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"audit request", "last request", "last response", "audit response"}, order)
+	// the audit middleware ran before the request was logged
+	assert.Contains(t, b.String(), `"X-Audit":["audited"]`)
+	assert.Contains(t, b.String(), `"interceptor":"unknown"`)
+}
+
+func TestTelemetrySuppression(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/health",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	b := &bytes.Buffer{}
+	rec := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"health": "/health"}),
+		WithObservability(
+			slog.New(slog.NewJSONHandler(b, nil)),
+			sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)),
+			sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+		),
+	)
+
+	ctx := WithTelemetrySuppression(context.Background())
+	res, err := client.NewRequest(ctx).Get(client.GetPath("health"))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Empty(t, b.String())
+	assert.Empty(t, rec.Ended())
+	assert.Empty(t, rec.Started())
+	assert.Empty(t, collectMetrics(t, reader))
+
+	_, err = client.NewRequest(context.Background()).Get(client.GetPath("health"))
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, b.String())
+	assert.Len(t, rec.Ended(), 1)
+	assert.NotEmpty(t, collectMetrics(t, reader))
+}
@@ -0,0 +1,116 @@
+package httpz
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"resty.dev/v3"
+)
+
+// latencyReservoirSize bounds the samples kept per path by
+// [WithLatencyStatsEnabled].
+const latencyReservoirSize = 1024
+
+// LatencyStats is the in-process latency summary of a path returned by
+// [Client.LatencyStats], the percentiles are computed over a uniform sample of
+// at most 1024 requests.
+type LatencyStats struct {
+	Count int64
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+// reservoir keeps a uniform sample of the recorded durations (algorithm R).
+type reservoir struct {
+	count   int64
+	samples []time.Duration
+}
+
+func (r *reservoir) record(d time.Duration) {
+	r.count++
+	if len(r.samples) < latencyReservoirSize {
+		r.samples = append(r.samples, d)
+		return
+	}
+	if i := rand.Int64N(r.count); i < latencyReservoirSize {
+		r.samples[i] = d
+	}
+}
+
+func (r *reservoir) stats() LatencyStats {
+	sorted := slices.Clone(r.samples)
+	slices.Sort(sorted)
+	return LatencyStats{
+		Count: r.count,
+		P50:   percentile(sorted, 0.50),
+		P95:   percentile(sorted, 0.95),
+		P99:   percentile(sorted, 0.99),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+type latencyRecorder struct {
+	mu    sync.Mutex
+	paths map[string]*reservoir
+}
+
+func newLatencyRecorder() *latencyRecorder {
+	return &latencyRecorder{paths: make(map[string]*reservoir)}
+}
+
+func (l *latencyRecorder) record(pathName string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r, ok := l.paths[pathName]
+	if !ok {
+		r = &reservoir{}
+		l.paths[pathName] = r
+	}
+	r.record(d)
+}
+
+// recordLatency records the duration of the responses to a named path.
+func recordLatency(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		if cfg.latency == nil || res.RawResponse == nil {
+			return nil
+		}
+
+		if name, ok := requestPathName(res.Request); ok {
+			cfg.latency.record(name, res.Duration())
+		}
+
+		return nil
+	}
+}
+
+// LatencyStats returns the p50/p95/p99 latency of the requests to every path
+// name given to [WithPaths] requested so far, e.g. for a CLI tool to print
+// without a metrics backend. It returns nil when [WithLatencyStatsEnabled]
+// isn't set.
+func (c *Client) LatencyStats() map[string]LatencyStats {
+	l := c.cfg.latency
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := make(map[string]LatencyStats, len(l.paths))
+	for name, r := range l.paths {
+		stats[name] = r.stats()
+	}
+
+	return stats
+}
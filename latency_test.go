@@ -0,0 +1,114 @@
+package httpz
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyStats(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/users/{id}",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(5 * time.Millisecond)
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/unnamed",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+	)
+
+	t.Run("populated after requests", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"getUser": "/test/users/{id}"}),
+			WithLatencyStatsEnabled(true),
+		)
+
+		for range 10 {
+			_, err := client.NewRequest(context.Background()).
+				SetPathParam("id", "1").
+				Get(client.GetPath("getUser"))
+			require.NoError(t, err)
+		}
+		_, err := client.NewRequest(context.Background()).Get("/test/unnamed")
+		require.NoError(t, err)
+
+		stats := client.LatencyStats()
+
+		require.Len(t, stats, 1)
+		s := stats["getUser"]
+		assert.Equal(t, int64(10), s.Count)
+		assert.GreaterOrEqual(t, s.P50, 5*time.Millisecond)
+		assert.LessOrEqual(t, s.P50, s.P95)
+		assert.LessOrEqual(t, s.P95, s.P99)
+	})
+
+	t.Run("shared template counts under the first name", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{
+				"getUser":    "/test/users/{id}",
+				"getAccount": "/test/users/{id}",
+			}),
+			WithLatencyStatsEnabled(true),
+		)
+
+		_, err := client.NewRequest(context.Background()).
+			SetPathParam("id", "1").
+			Get(client.GetPath("getUser"))
+		require.NoError(t, err)
+
+		stats := client.LatencyStats()
+		require.Len(t, stats, 1)
+		assert.Equal(t, int64(1), stats["getAccount"].Count)
+	})
+
+	t.Run("resolved path counts under its name", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"getUser": "/users/{id}"}),
+			WithPathResolver(func(_ context.Context, name string) string {
+				if name == "getUser" {
+					return "/test/users/{id}"
+				}
+				return ""
+			}),
+			WithLatencyStatsEnabled(true),
+		)
+
+		_, err := client.NewRequest(context.Background()).
+			SetPathParam("id", "1").
+			Get(client.GetPath("getUser"))
+		require.NoError(t, err)
+
+		stats := client.LatencyStats()
+		require.Len(t, stats, 1)
+		assert.Equal(t, int64(1), stats["getUser"].Count)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client := NewClient("test-client", server.URL)
+
+		assert.Nil(t, client.LatencyStats())
+	})
+}
+
+func TestReservoirBounded(t *testing.T) {
+	r := &reservoir{}
+
+	for i := range 10_000 {
+		r.record(time.Duration(i))
+	}
+
+	assert.Len(t, r.samples, latencyReservoirSize)
+	assert.Equal(t, int64(10_000), r.stats().Count)
+}
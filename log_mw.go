@@ -1,24 +1,50 @@
 package httpz
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
+	"math/rand/v2"
 
+	"github.com/goccy/go-json"
 	"github.com/unlimited-budget-ecommerce/logz"
+	"go.opentelemetry.io/otel/baggage"
 	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
 	"resty.dev/v3"
 )
 
 func logRequest(cfg *config) resty.RequestMiddleware {
 	return func(_ *resty.Client, req *resty.Request) error {
-		if !cfg.logMWEnabled {
+		if !cfg.logMWEnabled || !sampled(cfg.reqLogSampleRate) {
 			return nil
 		}
 
-		cfg.logger.InfoContext(req.Context(), "[HTTPZ][OUTGOING REQUEST] success",
+		body := req.Body
+		if r, ok := body.(io.Reader); ok && cfg.cloneReqBodyForLog {
+			b, err := cloneReaderBody(req, r)
+			if err != nil {
+				return fmt.Errorf("httpz: clone request body: %w", err)
+			}
+			body = string(b)
+			if cfg.reqBodyLogFormatter != nil {
+				body = bytes.NewReader(b)
+			}
+		}
+		if cfg.reqBodyLogFormatter != nil {
+			body = cfg.reqBodyLogFormatter(body)
+		}
+		body = truncateLogBody(body, cfg.maxLogBodyDepth)
+
+		ctx := req.Context()
+		attrs := append(baggageLogAttrs(cfg, ctx), requestIDLogAttrs(ctx)...)
+		logger := requestLogger(ctx, cfg).With(attrs...)
+		logger.InfoContext(ctx, "[HTTPZ][OUTGOING REQUEST] success",
 			slog.String(string(semconv.URLFullKey), req.URL),
 			slog.String(string(semconv.HTTPRequestMethodKey), req.Method),
 			slog.Any("http.request.header", logz.MaskHttpHeader(req.Header)),
-			slog.Any("http.request.body", req.Body),
+			slog.Any("http.request.body", body),
 		)
 
 		return nil
@@ -27,20 +53,42 @@ func logRequest(cfg *config) resty.RequestMiddleware {
 
 func logResponse(cfg *config) resty.ResponseMiddleware {
 	return func(_ *resty.Client, res *resty.Response) error {
-		if !cfg.logMWEnabled {
+		if !cfg.logMWEnabled || !sampled(cfg.resLogSampleRate) {
 			return nil
 		}
 
-		logger := cfg.logger.With(
+		attrs := []any{
 			slog.String(string(semconv.URLFullKey), res.Request.URL),
 			slog.String(string(semconv.HTTPRequestMethodKey), res.Request.Method),
 			slog.Duration(semconv.HTTPClientRequestDurationName, res.Duration()),
 			slog.Int(string(semconv.HTTPResponseStatusCodeKey), res.StatusCode()),
+			slog.Int(string(semconv.HTTPRequestResendCountKey), res.Request.Attempt-1),
 			slog.Any("http.response.header", logz.MaskHttpHeader(res.Header())),
-			slog.Any("http.response.body", res.Result()),
-		)
+		}
+		switch {
+		case res.Request.ForceResponseContentType == octetStream:
+			if size, ok := responseBodySize(res); ok {
+				attrs = append(attrs, slog.Int64(string(semconv.HTTPResponseBodySizeKey), size))
+			}
+		case !cfg.logBodyOnErrorOnly:
+			attrs = append(attrs, slog.Any("http.response.body", truncateLogBody(res.Result(), cfg.maxLogBodyDepth)))
+		case res.IsError():
+			attrs = append(attrs, errorBodyLogAttr(res, cfg.maxLogBodyDepth))
+		}
+
+		if attr, ok := trailerLogAttr(cfg, res); ok {
+			attrs = append(attrs, attr)
+		}
 
 		ctx := res.Request.Context()
+		attrs = append(attrs, baggageLogAttrs(cfg, ctx)...)
+		attrs = append(attrs, requestIDLogAttrs(ctx)...)
+		logger := requestLogger(ctx, cfg).With(attrs...)
+
+		if cfg.serverTimingEnabled {
+			logger = logger.With(serverTimingLogAttr(res))
+		}
+
 		if res.IsError() {
 			logger.ErrorContext(ctx, "[HTTPZ][INCOMING RESPONSE] error")
 		} else {
@@ -50,3 +98,141 @@ func logResponse(cfg *config) resty.ResponseMiddleware {
 		return nil
 	}
 }
+
+// requestIDLogAttrs returns the [WithOutgoingRequestID] request ID of ctx as a
+// log attribute, if any.
+func requestIDLogAttrs(ctx context.Context) []any {
+	if id, ok := RequestIDFromContext(ctx); ok {
+		return []any{slog.String(string(requestIDAttrKey), id)}
+	}
+	return nil
+}
+
+// requestLogger returns the logger of [WithLoggerFromContext] for ctx, or the
+// client logger.
+func requestLogger(ctx context.Context, cfg *config) *slog.Logger {
+	if cfg.ctxLogger != nil {
+		if l := cfg.ctxLogger(ctx); l != nil {
+			return l
+		}
+	}
+	return cfg.logger
+}
+
+// baggageLogAttrs returns the [WithLogBaggageKeys] baggage members of ctx as
+// "baggage.<key>" log attributes.
+func baggageLogAttrs(cfg *config, ctx context.Context) []any {
+	if len(cfg.logBaggageKeys) == 0 {
+		return nil
+	}
+	bag := baggage.FromContext(ctx)
+	var attrs []any
+	for _, key := range cfg.logBaggageKeys {
+		if m := bag.Member(key); m.Key() != "" {
+			attrs = append(attrs, slog.String("baggage."+key, m.Value()))
+		}
+	}
+	return attrs
+}
+
+// cloneReaderBody returns the unread bytes of the reader body r without
+// consuming them for the request: a seeker is rewound, any other reader is
+// buffered and replaced with a reader of its bytes.
+func cloneReaderBody(req *resty.Request, r io.Reader) ([]byte, error) {
+	if rs, ok := r.(io.ReadSeeker); ok {
+		pos, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		b, err := io.ReadAll(rs)
+		if err != nil {
+			return nil, err
+		}
+		_, err = rs.Seek(pos, io.SeekStart)
+		return b, err
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBody(bytes.NewReader(b))
+
+	return b, nil
+}
+
+// errorBodyLogAttr returns the decoded error value of res, or its raw body when
+// there's none, truncated at maxDepth, see [truncateLogBody].
+func errorBodyLogAttr(res *resty.Response, maxDepth int) slog.Attr {
+	if e := res.Error(); e != nil {
+		return slog.Any("http.response.body", truncateLogBody(e, maxDepth))
+	}
+	return slog.Any("http.response.body", truncateLogBody(res.String(), maxDepth))
+}
+
+// truncateLogBody returns a copy of the JSON body replacing the objects and
+// arrays nested deeper than maxDepth with "{...}" and "[...]", the top level
+// being depth 1. A JSON string body stays a string, a body that isn't JSON or a
+// maxDepth of 0 or less returns body as is.
+func truncateLogBody(body any, maxDepth int) any {
+	if maxDepth <= 0 || body == nil {
+		return body
+	}
+
+	var raw []byte
+	switch b := body.(type) {
+	case string:
+		raw = []byte(b)
+	case []byte:
+		raw = b
+	case io.Reader:
+		return body
+	default:
+		var err error
+		if raw, err = json.Marshal(b); err != nil {
+			return body
+		}
+	}
+
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return body
+	}
+	v = truncateJSON(v, 1, maxDepth)
+
+	switch body.(type) {
+	case string, []byte:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return body
+		}
+		return string(b)
+	}
+	return v
+}
+
+func truncateJSON(v any, depth, maxDepth int) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if depth > maxDepth {
+			return "{...}"
+		}
+		for k, e := range v {
+			v[k] = truncateJSON(e, depth+1, maxDepth)
+		}
+	case []any:
+		if depth > maxDepth {
+			return "[...]"
+		}
+		for i, e := range v {
+			v[i] = truncateJSON(e, depth+1, maxDepth)
+		}
+	}
+	return v
+}
+
+// sampled reports whether a log is kept at the given sample rate, a nil rate
+// keeps every log.
+func sampled(rate *float64) bool {
+	return rate == nil || rand.Float64() < *rate
+}
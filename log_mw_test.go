@@ -6,12 +6,17 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestLogMiddleware(t *testing.T) {
@@ -182,3 +187,440 @@ func TestConcurrentLogMiddleware(t *testing.T) {
 		}()
 	}
 }
+
+func TestLogMiddlewareRequestBodyFormatter(t *testing.T) {
+	type testLogReq struct {
+		Items []string `json:"items"`
+	}
+	wantReqBody := testLogReq{Items: []string{"a", "b", "c"}}
+	server := startTestServer(t, testHandler{
+		method: http.MethodPost,
+		path:   "/test/log/formatter",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			var reqBody testLogReq
+
+			err := json.NewDecoder(r.Body).Decode(&reqBody)
+
+			assert.NoError(t, err)
+			assert.Equal(t, wantReqBody, reqBody)
+
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	b := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(b, nil))
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"testLogFormatter": "/test/log/formatter"}),
+		WithLogger(logger),
+		WithLogMWEnabled(true),
+		WithRequestBodyLogFormatter(func(body any) any {
+			if req, ok := body.(testLogReq); ok {
+				return map[string]int{"items": len(req.Items)}
+			}
+			return body
+		}),
+	)
+
+	res, err := client.NewRequest(context.Background()).
+		SetBody(wantReqBody).
+		Post(client.GetPath("testLogFormatter"))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+
+	logs := b.String()
+
+	assert.Contains(t, logs, `"http.request.body":{"items":3}`)
+	assert.NotContains(t, logs, `"a","b","c"`)
+}
+
+func TestLogMiddlewareResendCount(t *testing.T) {
+	attempts := 0
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/log/retry",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"retry": "/test/log/retry"}),
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+	)
+	client.SetRetryCount(2).
+		SetRetryWaitTime(time.Millisecond).
+		SetRetryMaxWaitTime(time.Millisecond)
+
+	res, err := client.NewRequest(context.Background()).Get(client.GetPath("retry"))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	logs := b.String()
+	assert.Contains(t, logs, `"http.response.status_code":503,"http.request.resend_count":0`)
+	assert.Contains(t, logs, `"http.response.status_code":503,"http.request.resend_count":1`)
+	assert.Contains(t, logs, `"http.response.status_code":200,"http.request.resend_count":2`)
+}
+
+func TestLogMiddlewareSampleRate(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/log/sample",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"sample": "/test/log/sample"}),
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+		WithRequestLogSampleRate(0),
+		WithResponseLogSampleRate(1),
+	)
+
+	for range 10 {
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("sample"))
+		require.NoError(t, err)
+	}
+
+	logs := b.String()
+	assert.NotContains(t, logs, "[HTTPZ][OUTGOING REQUEST]")
+	assert.Equal(t, 10, strings.Count(logs, "[HTTPZ][INCOMING RESPONSE]"))
+}
+
+func TestLogMiddlewareBodyOnErrorOnly(t *testing.T) {
+	handler := func(code int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+			_, _ = w.Write([]byte(`{"message":"secret-` + http.StatusText(code) + `"}`))
+		}
+	}
+	server := startTestServer(t,
+		testHandler{method: http.MethodGet, path: "/test/log/ok", handlerFunc: handler(http.StatusOK)},
+		testHandler{method: http.MethodGet, path: "/test/log/fail", handlerFunc: handler(http.StatusInternalServerError)},
+	)
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{
+			"ok":   "/test/log/ok",
+			"fail": "/test/log/fail",
+		}),
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+		WithLogBodyOnErrorOnly(true),
+	)
+	type testRes struct {
+		Message string `json:"message"`
+	}
+
+	t.Run("200 omits the body", func(t *testing.T) {
+		b.Reset()
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(&testRes{}).
+			Get(client.GetPath("ok"))
+
+		require.NoError(t, err)
+		assert.NotContains(t, b.String(), "http.response.body")
+		assert.NotContains(t, b.String(), "secret-OK")
+	})
+
+	t.Run("500 includes the body", func(t *testing.T) {
+		b.Reset()
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("fail"))
+
+		require.NoError(t, err)
+		assert.Contains(t, b.String(), "http.response.body")
+		assert.Contains(t, b.String(), "secret-Internal Server Error")
+	})
+
+	t.Run("500 includes the error value", func(t *testing.T) {
+		b.Reset()
+
+		_, err := client.NewRequest(context.Background()).
+			SetError(&testRes{}).
+			Get(client.GetPath("fail"))
+
+		require.NoError(t, err)
+		assert.Contains(t, b.String(), `"http.response.body":{"message":"secret-Internal Server Error"}`)
+	})
+}
+
+func TestLogMiddlewareChunkedResponse(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/log/chunked",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.WriteHeader(http.StatusOK)
+			for range 3 {
+				_, _ = w.Write([]byte("chunk"))
+				w.(http.Flusher).Flush()
+			}
+		},
+	})
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"chunked": "/test/log/chunked"}),
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+	)
+
+	t.Run("read body logs the bytes read", func(t *testing.T) {
+		b.Reset()
+
+		body, res, err := client.GetBytes(context.Background(), client.GetPath("chunked"))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), res.RawResponse.ContentLength)
+		assert.Equal(t, "chunkchunkchunk", string(body))
+		assert.Contains(t, b.String(), `"http.response.body.size":15`)
+	})
+
+	t.Run("unread body logs no size", func(t *testing.T) {
+		b.Reset()
+
+		res, err := client.NewRequest(context.Background()).
+			SetForceResponseContentType(octetStream).
+			SetDoNotParseResponse(true).
+			Get(client.GetPath("chunked"))
+
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Contains(t, b.String(), "[HTTPZ][INCOMING RESPONSE]")
+		assert.NotContains(t, b.String(), "http.response.body.size")
+	})
+}
+
+func TestLogMiddlewareRequestCloneForLogging(t *testing.T) {
+	const wantBody = `{"name":"Alice"}`
+	var gotBody string
+	server := startTestServer(t, testHandler{
+		method: http.MethodPost,
+		path:   "/test/log/reader",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			b, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			gotBody = string(b)
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"reader": "/test/log/reader"}),
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+		WithRequestCloneForLogging(true),
+		// a formatter consuming the reader it's given
+		WithRequestBodyLogFormatter(func(body any) any {
+			if r, ok := body.(io.Reader); ok {
+				raw, _ := io.ReadAll(r)
+				return string(raw)
+			}
+			return body
+		}),
+	)
+	bodies := map[string]func() io.Reader{
+		"seeker":     func() io.Reader { return strings.NewReader(wantBody) },
+		"non-seeker": func() io.Reader { return io.MultiReader(strings.NewReader(wantBody)) },
+	}
+
+	for name, newBody := range bodies {
+		t.Run(name, func(t *testing.T) {
+			b.Reset()
+			gotBody = ""
+
+			res, err := client.NewRequest(context.Background()).
+				SetBody(newBody()).
+				Post(client.GetPath("reader"))
+
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode())
+			assert.Equal(t, wantBody, gotBody)
+			assert.Contains(t, b.String(), `"http.request.body":"{\"name\":\"Alice\"}"`)
+		})
+	}
+}
+
+func TestLogMiddlewareLoggerFromContext(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/log/ctx",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	type loggerKey struct{}
+	clientLogs := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"ctx": "/test/log/ctx"}),
+		WithLogger(slog.New(slog.NewJSONHandler(clientLogs, nil))),
+		WithLoggerFromContext(func(ctx context.Context) *slog.Logger {
+			l, _ := ctx.Value(loggerKey{}).(*slog.Logger)
+			return l
+		}),
+		WithLogMWEnabled(true),
+	)
+
+	t.Run("context logger", func(t *testing.T) {
+		clientLogs.Reset()
+		ctxLogs := &bytes.Buffer{}
+		ctxLogger := slog.New(slog.NewJSONHandler(ctxLogs, nil)).With(slog.String("request_id", "req-1"))
+		ctx := context.WithValue(context.Background(), loggerKey{}, ctxLogger)
+
+		_, err := client.NewRequest(ctx).Get(client.GetPath("ctx"))
+
+		require.NoError(t, err)
+		assert.Empty(t, clientLogs.String())
+		logs := ctxLogs.String()
+		assert.Contains(t, logs, "[HTTPZ][OUTGOING REQUEST]")
+		assert.Contains(t, logs, "[HTTPZ][INCOMING RESPONSE]")
+		assert.Equal(t, 2, strings.Count(logs, `"request_id":"req-1"`))
+	})
+
+	t.Run("fallback to client logger", func(t *testing.T) {
+		clientLogs.Reset()
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("ctx"))
+
+		require.NoError(t, err)
+		logs := clientLogs.String()
+		assert.Contains(t, logs, "[HTTPZ][OUTGOING REQUEST]")
+		assert.Contains(t, logs, "[HTTPZ][INCOMING RESPONSE]")
+	})
+}
+
+func TestLogMiddlewareBaggage(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/log/baggage",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"baggage": "/test/log/baggage"}),
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+		WithLogBaggageKeys([]string{"tenant.id"}),
+	)
+	tenant, err := baggage.NewMember("tenant.id", "tenant-1")
+	require.NoError(t, err)
+	session, err := baggage.NewMember("session.token", "secret")
+	require.NoError(t, err)
+	bag, err := baggage.New(tenant, session)
+	require.NoError(t, err)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	_, err = client.NewRequest(ctx).Get(client.GetPath("baggage"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(b.String(), `"baggage.tenant.id":"tenant-1"`))
+	assert.NotContains(t, b.String(), "secret")
+}
+
+func TestOutgoingRequestID(t *testing.T) {
+	var headers []string
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/log/request-id",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			headers = append(headers, r.Header.Get("X-Request-Id"))
+			if len(headers) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	b := &bytes.Buffer{}
+	rec := tracetest.NewSpanRecorder()
+	client := NewClient("test-client", server.URL,
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+		WithTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))),
+		WithOtelMWEnabled(true),
+		WithOutgoingRequestID(""),
+	)
+	client.SetRetryCount(1).
+		SetRetryWaitTime(time.Millisecond).
+		SetRetryMaxWaitTime(time.Millisecond)
+
+	res, err := client.NewRequest(context.Background()).Get("/test/log/request-id")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	id, ok := RequestIDFromContext(res.Request.Context())
+	require.True(t, ok)
+	assert.Equal(t, []string{id, id}, headers)
+
+	var requestLogs, responseLogs int
+	for line := range strings.Lines(b.String()) {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, id, entry["httpz.request.id"], entry["msg"])
+		if strings.Contains(entry["msg"].(string), "OUTGOING REQUEST") {
+			requestLogs++
+		} else {
+			responseLogs++
+		}
+	}
+	assert.Equal(t, 2, requestLogs)
+	assert.Equal(t, 2, responseLogs)
+
+	spans := rec.Ended()
+	require.NotEmpty(t, spans)
+	for _, span := range spans {
+		assert.Contains(t, span.Attributes(), requestIDAttrKey.String(id))
+	}
+}
+
+func TestLogMiddlewareMaxBodyDepth(t *testing.T) {
+	const nested = `{"l1":{"l2":{"l3":{"l4":{"l5":1}}},"list":[[1]],"id":2}}`
+	server := startTestServer(t, testHandler{
+		method: http.MethodPost,
+		path:   "/test/log/depth",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(nested))
+		},
+	})
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+		WithMaxLogBodyDepth(2),
+	)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal([]byte(nested), &body))
+
+	res, err := client.NewRequest(context.Background()).
+		SetBody(body).
+		SetResult(&map[string]any{}).
+		Post("/test/log/depth")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	const truncated = `{"l1":{"id":2,"l2":"{...}","list":"[...]"}}`
+	logs := b.String()
+	assert.Contains(t, logs, `"http.request.body":`+truncated)
+	assert.Contains(t, logs, `"http.response.body":`+truncated)
+	assert.IsType(t, map[string]any{}, body["l1"].(map[string]any)["l2"], "the request body is untouched")
+}
+
+func TestTruncateLogBody(t *testing.T) {
+	assert.Equal(t, `{"a":"[...]"}`, truncateLogBody(`{"a":[{"b":1}]}`, 1))
+	assert.Equal(t, "not json", truncateLogBody("not json", 1))
+	assert.Equal(t, `{"a":[{"b":1}]}`, truncateLogBody(`{"a":[{"b":1}]}`, 0))
+}
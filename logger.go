@@ -1,9 +1,13 @@
 package httpz
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
 
+	"github.com/unlimited-budget-ecommerce/logz"
+	"go.opentelemetry.io/otel/trace"
 	"resty.dev/v3"
 )
 
@@ -22,3 +26,41 @@ func (l logger) Warnf(format string, v ...any) {
 func (l logger) Errorf(format string, v ...any) {
 	l.Error("[HTTPZ] " + fmt.Sprintf(format, v...))
 }
+
+// LogFormat is the output format of [NewLogHandler].
+type LogFormat string
+
+const (
+	LogFormatJSON LogFormat = "json"
+	LogFormatText LogFormat = "text"
+)
+
+// NewLogHandler returns the recommended [slog.Handler] for httpz logs, writing
+// to w in format (JSON when unknown). Records logged with a context carrying a
+// span get its "trace_id" and "span_id", the same keys as logz.
+func NewLogHandler(format LogFormat, w io.Writer) slog.Handler {
+	if format == LogFormatText {
+		return &traceHandler{slog.NewTextHandler(w, nil)}
+	}
+	return &traceHandler{slog.NewJSONHandler(w, nil)}
+}
+
+type traceHandler struct{ slog.Handler }
+
+func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String(logz.TraceKey, sc.TraceID().String()),
+			slog.String(logz.SpanKey, sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	return &traceHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,60 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestNewLogHandler(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/log",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "parent")
+	defer span.End()
+
+	t.Run("json", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"log": "/test/log"}),
+			WithLogger(slog.New(NewLogHandler(LogFormatJSON, b))),
+			WithLogMWEnabled(true),
+		)
+
+		_, err := client.NewRequest(ctx).Get(client.GetPath("log"))
+
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		require.Len(t, lines, 2)
+		for _, line := range lines {
+			var record map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			for _, key := range []string{"time", "level", "msg", "url.full", "trace_id", "span_id"} {
+				assert.Contains(t, record, key)
+			}
+			assert.Equal(t, span.SpanContext().TraceID().String(), record["trace_id"])
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		logger := slog.New(NewLogHandler(LogFormatText, b))
+
+		logger.InfoContext(ctx, "hello")
+
+		assert.Contains(t, b.String(), "level=INFO msg=hello trace_id="+span.SpanContext().TraceID().String())
+	})
+}
@@ -0,0 +1,257 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"resty.dev/v3"
+)
+
+type instruments struct {
+	inflight    metric.Int64UpDownCounter
+	resSize     metric.Int64Histogram
+	duration    metric.Float64Histogram
+	connections metric.Int64Counter
+	cbDenials   metric.Int64Counter
+}
+
+// connectionsName is the counter of the connections used by requests, with
+// the [connReusedKey] attribute telling a reused one from a newly dialed one.
+const connectionsName = "http.client.connection.uses"
+
+const connReusedKey = attribute.Key("http.connection.reused")
+
+// cbDenialsName is the counter of the requests refused by the open circuit
+// breaker, with the [pathNameKey] attribute.
+const cbDenialsName = "http.client.circuit_breaker.denials"
+
+const pathNameKey = attribute.Key("httpz.path.name")
+
+// defaultDurationBuckets are the semconv recommended
+// http.client.request.duration bucket boundaries in seconds.
+var defaultDurationBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10,
+}
+
+func newInstruments(cfg *config) *instruments {
+	meter := cfg.meter.Meter("httpz-metrics-middleware")
+
+	inflight, err := meter.Int64UpDownCounter(
+		cfg.metricsNamespace+semconv.HTTPClientActiveRequestsName,
+		metric.WithDescription(semconv.HTTPClientActiveRequestsDescription),
+		metric.WithUnit(semconv.HTTPClientActiveRequestsUnit),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	resSize, err := meter.Int64Histogram(
+		cfg.metricsNamespace+semconv.HTTPClientResponseBodySizeName,
+		metric.WithDescription(semconv.HTTPClientResponseBodySizeDescription),
+		metric.WithUnit(semconv.HTTPClientResponseBodySizeUnit),
+		metric.WithExplicitBucketBoundaries(0, 100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000, 100_000_000),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	buckets := defaultDurationBuckets
+	if len(cfg.durationBuckets) > 0 {
+		buckets = cfg.durationBuckets
+	}
+	duration, err := meter.Float64Histogram(
+		cfg.metricsNamespace+semconv.HTTPClientRequestDurationName,
+		metric.WithDescription(semconv.HTTPClientRequestDurationDescription),
+		metric.WithUnit(semconv.HTTPClientRequestDurationUnit),
+		metric.WithExplicitBucketBoundaries(buckets...),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	connections, err := meter.Int64Counter(
+		cfg.metricsNamespace+connectionsName,
+		metric.WithDescription("Number of connections used by requests, reused or newly dialed."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	cbDenials, err := meter.Int64Counter(
+		cfg.metricsNamespace+cbDenialsName,
+		metric.WithDescription("Number of requests refused by the open circuit breaker."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &instruments{
+		inflight:    inflight,
+		resSize:     resSize,
+		duration:    duration,
+		connections: connections,
+		cbDenials:   cbDenials,
+	}
+}
+
+type inflightCtxKey struct{}
+
+// inflightAttempt is stored in the request context for every attempt, so the
+// in-flight counter is decremented exactly once whichever hook sees it first.
+type inflightAttempt struct {
+	once sync.Once
+	opt  metric.AddOption
+}
+
+func (a *inflightAttempt) end(ctx context.Context, counter metric.Int64UpDownCounter) {
+	a.once.Do(func() {
+		counter.Add(ctx, -1, a.opt)
+	})
+}
+
+func startInflight(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if !cfg.metricsMWEnabled {
+			return nil
+		}
+
+		ctx := req.Context()
+		attempt := &inflightAttempt{
+			opt: metric.WithAttributes(semconv.HTTPRequestMethodKey.String(req.Method)),
+		}
+		cfg.instruments.inflight.Add(ctx, 1, attempt.opt)
+		req.SetContext(context.WithValue(ctx, inflightCtxKey{}, attempt))
+
+		return nil
+	}
+}
+
+type connTraceCtxKey struct{}
+
+// traceConnReuse counts the connection used by every attempt, reused from the
+// idle pool or newly dialed, telling whether keep-alive is effective. The
+// trace is attached once, the context of a retried request keeps it.
+func traceConnReuse(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if !cfg.metricsMWEnabled || req.Context().Value(connTraceCtxKey{}) != nil {
+			return nil
+		}
+
+		ctx := context.WithValue(req.Context(), connTraceCtxKey{}, true)
+		req.SetContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				cfg.instruments.connections.Add(ctx, 1, metric.WithAttributes(connReusedKey.Bool(info.Reused)))
+			},
+		}))
+
+		return nil
+	}
+}
+
+func endInflight(cfg *config, req *resty.Request) {
+	if !cfg.metricsMWEnabled || req == nil {
+		return
+	}
+
+	ctx := req.Context()
+	if attempt, ok := ctx.Value(inflightCtxKey{}).(*inflightAttempt); ok {
+		attempt.end(ctx, cfg.instruments.inflight)
+	}
+}
+
+func endInflightResponse(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		endInflight(cfg, res.Request)
+		return nil
+	}
+}
+
+func endInflightSuccess(cfg *config) resty.SuccessHook {
+	return func(_ *resty.Client, res *resty.Response) {
+		endInflight(cfg, res.Request)
+	}
+}
+
+func endInflightError(cfg *config) resty.ErrorHook {
+	return func(req *resty.Request, _ error) {
+		endInflight(cfg, req)
+	}
+}
+
+// endInflightRetry ends an attempt that failed without reaching the response
+// middlewares, e.g. on a transport error, before the next attempt starts.
+func endInflightRetry(cfg *config) resty.RetryHookFunc {
+	return func(res *resty.Response, _ error) {
+		if res != nil {
+			endInflight(cfg, res.Request)
+		}
+	}
+}
+
+// recordResponse records the request duration and the response body size, when
+// known, see [responseBodySize].
+func recordResponse(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		if !cfg.metricsMWEnabled || res.RawResponse == nil {
+			return nil
+		}
+
+		ctx := res.Request.Context()
+		opt := metric.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(res.Request.Method),
+			statusClassKey.String(statusClass(res.StatusCode())),
+		)
+
+		cfg.instruments.duration.Record(ctx, res.Duration().Seconds(), opt)
+
+		if size, ok := responseBodySize(res); ok {
+			cfg.instruments.resSize.Record(ctx, size, opt)
+		}
+
+		return nil
+	}
+}
+
+const cbDenialReasonKey = attribute.Key("httpz.circuit_breaker.denial_reason")
+
+// countCircuitBreakerDenial counts the requests refused by the open or warming
+// up circuit breaker apart from the failed ones, labeled by path name and
+// reason, "open" or "warmup".
+func countCircuitBreakerDenial(cfg *config) resty.ErrorHook {
+	return func(req *resty.Request, err error) {
+		if !cfg.metricsMWEnabled || !cfg.cbDenialMetrics || telemetrySuppressed(req.Context()) {
+			return
+		}
+
+		var reason string
+		switch {
+		case errors.Is(err, resty.ErrCircuitBreakerOpen):
+			reason = "open"
+		case errors.Is(err, ErrCircuitBreakerWarmup):
+			reason = "warmup"
+		default:
+			return
+		}
+		attrs := []attribute.KeyValue{cbDenialReasonKey.String(reason)}
+		if name, ok := lookupPathName(cfg, req.URL); ok {
+			attrs = append(attrs, pathNameKey.String(name))
+		}
+		cfg.instruments.cbDenials.Add(req.Context(), 1, metric.WithAttributes(attrs...))
+	}
+}
+
+const statusClassKey = attribute.Key("http.response.status_class")
+
+// statusClass returns the class of code, e.g. "2xx".
+func statusClass(code int) string {
+	return fmt.Sprintf("%dxx", code/100)
+}
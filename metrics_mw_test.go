@@ -0,0 +1,446 @@
+package httpz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"resty.dev/v3"
+)
+
+func TestMetricsInflightGauge(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/metrics",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/metrics/slow",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-release
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+	)
+	paths := map[string]string{
+		"metrics":     "/test/metrics",
+		"metricsSlow": "/test/metrics/slow",
+	}
+
+	t.Run("metrics middleware disabled", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithMeter(mp),
+			WithMetricsMWEnabled(false),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("metrics"))
+
+		require.NoError(t, err)
+		assert.Empty(t, collectMetrics(t, reader))
+	})
+
+	t.Run("in-flight while waiting and zero after", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithMeter(mp),
+			WithMetricsMWEnabled(true),
+		)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := client.NewRequest(context.Background()).Get(client.GetPath("metricsSlow"))
+			assert.NoError(t, err)
+		}()
+		<-started
+
+		assert.Equal(t, int64(1), inflightValue(t, reader, http.MethodGet))
+
+		close(release)
+		<-done
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("metrics"))
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), inflightValue(t, reader, http.MethodGet))
+	})
+
+	t.Run("zero after retried transport errors", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		client := NewClient("test-client", closed.URL,
+			WithMeter(mp),
+			WithMetricsMWEnabled(true),
+		)
+		client.SetRetryCount(2)
+		client.SetRetryWaitTime(1 * time.Millisecond)
+		client.SetRetryMaxWaitTime(1 * time.Millisecond)
+
+		_, err := client.NewRequest(context.Background()).Get("/test")
+
+		require.Error(t, err)
+		assert.Equal(t, int64(0), inflightValue(t, reader, http.MethodGet))
+	})
+
+	t.Run("zero after panic", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithMeter(mp),
+			WithMetricsMWEnabled(true),
+		)
+		// resty middlewares added after NewClient don't recover
+		client.Client.AddRequestMiddleware(func(_ *resty.Client, _ *resty.Request) error {
+			panic("boom")
+		})
+
+		assert.Panics(t, func() {
+			_, _ = client.NewRequest(context.Background()).Get(client.GetPath("metrics"))
+		})
+		assert.Equal(t, int64(0), inflightValue(t, reader, http.MethodGet))
+	})
+}
+
+func TestMetricsNamespace(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/metrics",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"metrics": "/test/metrics"}),
+		WithMeter(mp),
+		WithMetricsMWEnabled(true),
+		WithMetricsNamespace("myservice."),
+	)
+
+	_, err := client.NewRequest(context.Background()).Get(client.GetPath("metrics"))
+
+	require.NoError(t, err)
+	metrics := collectMetrics(t, reader)
+	require.NotEmpty(t, metrics)
+	for _, m := range metrics {
+		assert.True(t, strings.HasPrefix(m.Name, "myservice."), m.Name)
+	}
+	findMetric(t, reader, "myservice."+semconv.HTTPClientActiveRequestsName)
+}
+
+func TestMetricsResponseSizeHistogram(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/metrics/sized",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "1500")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(strings.Repeat("x", 1500)))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/metrics/chunked",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+				w.(http.Flusher).Flush()
+				_, _ = w.Write([]byte(strings.Repeat("x", 50)))
+			},
+		},
+	)
+	paths := map[string]string{
+		"sized":   "/test/metrics/sized",
+		"chunked": "/test/metrics/chunked",
+	}
+
+	t.Run("known content length", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithMeter(mp),
+			WithMetricsMWEnabled(true),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("sized"))
+
+		require.NoError(t, err)
+		dp := responseSizeDataPoint(t, reader)
+		assert.Equal(t, uint64(1), dp.Count)
+		assert.Equal(t, int64(1500), dp.Sum)
+		assert.Equal(t, uint64(1), dp.BucketCounts[3]) // (1000, 10000]
+		class, _ := dp.Attributes.Value(statusClassKey)
+		assert.Equal(t, "2xx", class.AsString())
+	})
+
+	t.Run("unknown content length", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithMeter(mp),
+			WithMetricsMWEnabled(true),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("chunked"))
+
+		require.NoError(t, err)
+		dp := responseSizeDataPoint(t, reader)
+		assert.Equal(t, int64(150), dp.Sum)
+		assert.Equal(t, uint64(1), dp.BucketCounts[2]) // (100, 1000]
+		class, _ := dp.Attributes.Value(statusClassKey)
+		assert.Equal(t, "4xx", class.AsString())
+	})
+}
+
+func responseSizeDataPoint(t *testing.T, reader *sdkmetric.ManualReader) metricdata.HistogramDataPoint[int64] {
+	t.Helper()
+	m := findMetric(t, reader, semconv.HTTPClientResponseBodySizeName)
+	hist, ok := m.Data.(metricdata.Histogram[int64])
+	require.True(t, ok)
+	require.Len(t, hist.DataPoints, 1)
+	return hist.DataPoints[0]
+}
+
+func TestMetricsDurationBuckets(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/metrics",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(5 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	paths := map[string]string{"metrics": "/test/metrics"}
+
+	t.Run("default buckets", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithMeter(mp),
+			WithMetricsMWEnabled(true),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("metrics"))
+
+		require.NoError(t, err)
+		dp := durationDataPoint(t, reader)
+		assert.Equal(t, defaultDurationBuckets, dp.Bounds)
+		assert.Equal(t, uint64(1), dp.Count)
+	})
+
+	t.Run("custom buckets", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithMeter(mp),
+			WithMetricsMWEnabled(true),
+			WithMetricsDurationBuckets([]float64{0.001, 0.002}),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("metrics"))
+
+		require.NoError(t, err)
+		dp := durationDataPoint(t, reader)
+		assert.Equal(t, []float64{0.001, 0.002}, dp.Bounds)
+		assert.Equal(t, []uint64{0, 0, 1}, dp.BucketCounts)
+		assert.GreaterOrEqual(t, dp.Sum, 0.005)
+	})
+}
+
+func durationDataPoint(t *testing.T, reader *sdkmetric.ManualReader) metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+	m := findMetric(t, reader, semconv.HTTPClientRequestDurationName)
+	hist, ok := m.Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, hist.DataPoints, 1)
+	return hist.DataPoints[0]
+}
+
+func collectMetrics(t *testing.T, reader *sdkmetric.ManualReader) []metricdata.Metrics {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var metrics []metricdata.Metrics
+	for _, sm := range rm.ScopeMetrics {
+		metrics = append(metrics, sm.Metrics...)
+	}
+	return metrics
+}
+
+func findMetric(t *testing.T, reader *sdkmetric.ManualReader, name string) metricdata.Metrics {
+	t.Helper()
+	for _, m := range collectMetrics(t, reader) {
+		if m.Name == name {
+			return m
+		}
+	}
+	require.Failf(t, "metric not found", "metric %q not recorded", name)
+	return metricdata.Metrics{}
+}
+
+func inflightValue(t *testing.T, reader *sdkmetric.ManualReader, method string) int64 {
+	t.Helper()
+	m := findMetric(t, reader, semconv.HTTPClientActiveRequestsName)
+	sum, ok := m.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	for _, dp := range sum.DataPoints {
+		if v, ok := dp.Attributes.Value(semconv.HTTPRequestMethodKey); ok && v.AsString() == method {
+			return dp.Value
+		}
+	}
+	return 0
+}
+
+func TestMetricsConnectionReuse(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/metrics",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		},
+	})
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	client := NewClient("test-client", server.URL,
+		WithTransport(&http.Transport{}),
+		WithMaxIdleConnDuration(time.Minute),
+		WithPaths(map[string]string{"metrics": "/test/metrics"}),
+		WithMeter(mp),
+		WithMetricsMWEnabled(true),
+	)
+
+	for range 3 {
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("metrics"))
+		require.NoError(t, err)
+	}
+
+	m := findMetric(t, reader, connectionsName)
+	sum, ok := m.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	counts := map[bool]int64{}
+	for _, dp := range sum.DataPoints {
+		reused, _ := dp.Attributes.Value(connReusedKey)
+		counts[reused.AsBool()] = dp.Value
+	}
+	assert.Equal(t, map[bool]int64{false: 1, true: 2}, counts)
+}
+
+func TestMetricsConnectionReuseRetried(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/metrics/retried",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	})
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	client := NewClient("test-client", server.URL,
+		WithTransport(&http.Transport{}),
+		WithMaxIdleConnDuration(time.Minute),
+		WithMeter(mp),
+		WithMetricsMWEnabled(true),
+	)
+	client.SetRetryCount(2).SetRetryWaitTime(time.Millisecond)
+
+	_, err := client.NewRequest(context.Background()).Get("/test/metrics/retried")
+	require.NoError(t, err)
+
+	m := findMetric(t, reader, connectionsName)
+	sum, ok := m.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	var total int64
+	for _, dp := range sum.DataPoints {
+		total += dp.Value
+	}
+	assert.Equal(t, int64(3), total)
+}
+
+func TestMetricsCircuitBreakerDenials(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/500",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+	})
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"fail": "/500"}),
+		WithCircuitBreaker(time.Minute, 1, 1),
+		WithCircuitBreakerEnabled(true),
+		WithMeter(mp),
+		WithMetricsMWEnabled(true),
+		WithMetricsForCircuitBreakerDenials(true),
+	)
+
+	_, err := client.NewRequest(context.Background()).Get(client.GetPath("fail"))
+	require.NoError(t, err)
+	for range 2 {
+		_, err = client.NewRequest(context.Background()).Get(client.GetPath("fail"))
+		require.ErrorIs(t, err, resty.ErrCircuitBreakerOpen)
+	}
+
+	m := findMetric(t, reader, cbDenialsName)
+	sum, ok := m.Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, int64(2), sum.DataPoints[0].Value)
+	name, _ := sum.DataPoints[0].Attributes.Value(pathNameKey)
+	assert.Equal(t, "fail", name.AsString())
+	reason, _ := sum.DataPoints[0].Attributes.Value(cbDenialReasonKey)
+	assert.Equal(t, "open", reason.AsString())
+
+	t.Run("warmup", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"fail": "/500"}),
+			WithMeter(mp),
+			WithMetricsMWEnabled(true),
+			WithMetricsForCircuitBreakerDenials(true),
+		)
+		// warming up since now for an hour, every request is throttled
+		client.cfg.cbWarmup = &cbWarmup{duration: time.Hour, start: time.Now()}
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("fail"))
+		require.ErrorIs(t, err, ErrCircuitBreakerWarmup)
+
+		m := findMetric(t, reader, cbDenialsName)
+		sum, ok := m.Data.(metricdata.Sum[int64])
+		require.True(t, ok)
+		require.Len(t, sum.DataPoints, 1)
+		assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+		reason, _ := sum.DataPoints[0].Attributes.Value(cbDenialReasonKey)
+		assert.Equal(t, "warmup", reason.AsString())
+	})
+}
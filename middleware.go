@@ -0,0 +1,112 @@
+package httpz
+
+import (
+	"slices"
+
+	"resty.dev/v3"
+)
+
+// Middleware is a built-in middleware of httpz, to compose a resty client
+// without [NewClient], see [RegisterMiddlewares].
+type Middleware struct {
+	Request  resty.RequestMiddleware
+	Response resty.ResponseMiddleware
+	// OnError ends what Request started for a request failing without a
+	// response reaching Response, may be nil.
+	OnError resty.ErrorHook
+	// OnRetry runs before a failed attempt is retried, e.g. to end what
+	// Request started for an attempt failing without a response, may be nil.
+	OnRetry resty.RetryHookFunc
+}
+
+// LoggingMiddleware returns the request/response logging middleware
+// configured with opts, e.g. [WithLogger], it's always enabled.
+func LoggingMiddleware(opts ...option) Middleware {
+	cfg := middlewareConfig(opts, func(cfg *config) { cfg.logMWEnabled = true })
+	return builtinMiddleware(cfg, InterceptorLog)
+}
+
+// TracingMiddleware returns the OpenTelemetry tracing middleware configured
+// with opts, e.g. [WithTracer], it's always enabled.
+func TracingMiddleware(opts ...option) Middleware {
+	cfg := middlewareConfig(opts, func(cfg *config) { cfg.otelMWEnabled = true })
+	return builtinMiddleware(cfg, InterceptorTrace)
+}
+
+// MetricsMiddleware returns the OpenTelemetry metrics middleware configured
+// with opts, e.g. [WithMeter], it's always enabled.
+func MetricsMiddleware(opts ...option) Middleware {
+	cfg := middlewareConfig(opts, func(cfg *config) { cfg.metricsMWEnabled = true })
+	return builtinMiddleware(cfg, InterceptorMetrics)
+}
+
+func middlewareConfig(opts []option, enable func(cfg *config)) *config {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	enable(cfg)
+	cfg.setDefaults("")
+	return cfg
+}
+
+// RegisterMiddlewares adds ms to c like [NewClient] adds its interceptor
+// chain: the request middlewares in order and the response middlewares in
+// reverse order, so the first middleware sees the request first and the
+// response last.
+//
+//	httpz.RegisterMiddlewares(restyClient,
+//		httpz.TracingMiddleware(httpz.WithTracer(tp)),
+//		httpz.MetricsMiddleware(httpz.WithMeter(mp)),
+//		httpz.LoggingMiddleware(httpz.WithLogger(logger)),
+//	)
+func RegisterMiddlewares(c *resty.Client, ms ...Middleware) *resty.Client {
+	for _, m := range ms {
+		if m.Request != nil {
+			c.AddRequestMiddleware(m.Request)
+		}
+		if m.OnError != nil {
+			c.OnError(m.OnError).OnInvalid(m.OnError).OnPanic(m.OnError)
+		}
+		if m.OnRetry != nil {
+			c.AddRetryHooks(m.OnRetry)
+		}
+	}
+	for _, m := range slices.Backward(ms) {
+		if m.Response != nil {
+			c.AddResponseMiddleware(m.Response)
+		}
+	}
+	return c
+}
+
+// builtinMiddleware returns the built-in middleware named name, skipping the
+// requests marked by [WithTelemetrySuppression], it must be a known name.
+func builtinMiddleware(cfg *config, name string) Middleware {
+	var m Middleware
+	switch name {
+	case InterceptorLog:
+		m = Middleware{
+			Request:  recoverRequest(cfg, logRequest(cfg)),
+			Response: recoverResponse(cfg, logResponse(cfg)),
+		}
+	case InterceptorTrace:
+		m = Middleware{
+			Request:  chainRequest(startTrace(cfg), setTraceIDHeader(cfg)),
+			Response: chainResponse(recordServerTiming(cfg), endTraceSuccess(cfg)),
+			OnError:  endTraceError(cfg),
+			OnRetry:  addAttemptError(cfg),
+		}
+	case InterceptorMetrics:
+		m = Middleware{
+			Request:  chainRequest(startInflight(cfg), traceConnReuse(cfg)),
+			Response: chainResponse(recordResponse(cfg), endInflightResponse(cfg)),
+			OnError:  endInflightError(cfg),
+			OnRetry:  endInflightRetry(cfg),
+		}
+	}
+
+	m.Request = unlessSuppressedRequest(m.Request)
+	m.Response = unlessSuppressedResponse(m.Response)
+	return m
+}
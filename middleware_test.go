@@ -0,0 +1,158 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"resty.dev/v3"
+)
+
+// spyEvents records the side effects of the built-in middlewares in order.
+type spyEvents struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (s *spyEvents) add(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *spyEvents) get() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events
+}
+
+type spyLogHandler struct {
+	slog.Handler
+	events *spyEvents
+}
+
+func (h spyLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	switch {
+	case strings.HasPrefix(r.Message, "[HTTPZ][OUTGOING REQUEST]"):
+		h.events.add("log request")
+	case strings.HasPrefix(r.Message, "[HTTPZ][INCOMING RESPONSE]"):
+		h.events.add("log response")
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h spyLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return spyLogHandler{Handler: h.Handler.WithAttrs(attrs), events: h.events}
+}
+
+type spySpanProcessor struct {
+	sdktrace.SpanProcessor
+	events *spyEvents
+}
+
+func (p spySpanProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	if s.Name() == "HTTP GET" {
+		p.events.add("trace start")
+	}
+}
+
+func (p spySpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.Name() == "HTTP GET" {
+		p.events.add("trace end")
+	}
+}
+
+func (p spySpanProcessor) Shutdown(context.Context) error   { return nil }
+func (p spySpanProcessor) ForceFlush(context.Context) error { return nil }
+
+type spyMeterProvider struct {
+	noop.MeterProvider
+	events *spyEvents
+}
+
+func (p spyMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter {
+	return spyMeter{events: p.events}
+}
+
+type spyMeter struct {
+	noop.Meter
+	events *spyEvents
+}
+
+func (m spyMeter) Int64UpDownCounter(string, ...metric.Int64UpDownCounterOption) (metric.Int64UpDownCounter, error) {
+	return spyInflight{events: m.events}, nil
+}
+
+type spyInflight struct {
+	noop.Int64UpDownCounter
+	events *spyEvents
+}
+
+func (c spyInflight) Add(_ context.Context, incr int64, _ ...metric.AddOption) {
+	if incr > 0 {
+		c.events.add("metrics start")
+	} else {
+		c.events.add("metrics end")
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/order",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	// the documented order, see [DefaultInterceptorChain]
+	want := []string{
+		"trace start",
+		"metrics start",
+		"log request",
+		"log response",
+		"metrics end",
+		"trace end",
+	}
+	newSpies := func() (*spyEvents, *slog.Logger, *sdktrace.TracerProvider, metric.MeterProvider) {
+		events := &spyEvents{}
+		logger := slog.New(spyLogHandler{Handler: slog.NewJSONHandler(&bytes.Buffer{}, nil), events: events})
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spySpanProcessor{events: events}))
+		return events, logger, tp, spyMeterProvider{events: events}
+	}
+
+	t.Run("NewClient", func(t *testing.T) {
+		events, logger, tp, mp := newSpies()
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"order": "/test/order"}),
+			WithObservability(logger, tp, mp),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("order"))
+
+		require.NoError(t, err)
+		assert.Equal(t, want, events.get())
+	})
+
+	t.Run("RegisterMiddlewares", func(t *testing.T) {
+		events, logger, tp, mp := newSpies()
+		c := RegisterMiddlewares(resty.New().SetBaseURL(server.URL),
+			TracingMiddleware(WithTracer(tp)),
+			MetricsMiddleware(WithMeter(mp)),
+			LoggingMiddleware(WithLogger(logger)),
+		)
+
+		_, err := c.R().Get("/test/order")
+
+		require.NoError(t, err)
+		assert.Equal(t, want, events.get())
+	})
+}
@@ -1,7 +1,10 @@
 package httpz
 
 import (
+	"context"
 	"log/slog"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/unlimited-budget-ecommerce/logz"
@@ -31,9 +34,13 @@ func startTrace(cfg *config) resty.RequestMiddleware {
 			trace.WithAttributes(
 				semconv.URLFull(req.URL),
 				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.PeerService(cfg.peerService),
 			),
 			trace.WithTimestamp(time.Now()),
 		)
+		if id, ok := RequestIDFromContext(ctx); ok {
+			span.SetAttributes(requestIDAttrKey.String(id))
+		}
 
 		attrs := []slog.Attr{
 			slog.String(logz.SpanKey, span.SpanContext().SpanID().String()),
@@ -43,6 +50,8 @@ func startTrace(cfg *config) resty.RequestMiddleware {
 		}
 		ctx = logz.SetContextAttrs(ctx, attrs...)
 
+		ctx = context.WithValue(ctx, spanCtxKey{}, &ownedSpan{req: req, span: span})
+
 		cfg.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
 		req.SetContext(ctx)
 
@@ -50,24 +59,74 @@ func startTrace(cfg *config) resty.RequestMiddleware {
 	}
 }
 
+type spanCtxKey struct{}
+
+// ownedSpan is the span started by [startTrace] for req.
+type ownedSpan struct {
+	req  *resty.Request
+	span trace.Span
+}
+
+// requestSpan returns the span started by [startTrace] for req, a request
+// failing before it, e.g. throttled, has none and the span of its context is
+// the caller's one, which mustn't be ended.
+func requestSpan(req *resty.Request) (trace.Span, bool) {
+	owned, ok := req.Context().Value(spanCtxKey{}).(*ownedSpan)
+	if !ok || owned.req != req {
+		return nil, false
+	}
+	return owned.span, true
+}
+
+// traceDecode wraps the resty response body decoding middleware with a
+// "http.response.decode" child span of the request span, so traces tell the
+// decode time apart from the network time.
+func traceDecode(cfg *config, decode resty.ResponseMiddleware) resty.ResponseMiddleware {
+	return func(c *resty.Client, res *resty.Response) error {
+		req := res.Request
+		if !cfg.otelMWEnabled || telemetrySuppressed(req.Context()) ||
+			res.Err != nil || req.DoNotParseResponse || (req.Result == nil && req.Error == nil) {
+			return decode(c, res)
+		}
+
+		_, span := cfg.tracer.Tracer("httpz-tracer-middleware").Start(req.Context(), "http.response.decode")
+		defer span.End()
+		err := decode(c, res)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		return err
+	}
+}
+
 func endTraceSuccess(cfg *config) resty.ResponseMiddleware {
 	return func(_ *resty.Client, res *resty.Response) error {
 		if !cfg.otelMWEnabled {
 			return nil
 		}
 
-		span := trace.SpanFromContext(res.Request.Context())
+		span, ok := requestSpan(res.Request)
+		if !ok {
+			return nil
+		}
 		defer span.End()
 		span.SetAttributes(
-			attribute.KeyValue{
-				Key:   semconv.HTTPClientRequestDurationName,
-				Value: attribute.Int64Value(res.Duration().Milliseconds()),
-			},
+			// in seconds, the unit of the semconv http.client.request.duration metric
+			attribute.Float64(semconv.HTTPClientRequestDurationName, res.Duration().Seconds()),
 			semconv.HTTPResponseStatusCode(res.StatusCode()),
 		)
+		span.SetAttributes(trailerSpanAttrs(cfg, res)...)
+		recordAttemptErrors(cfg, span, res.Request.Context())
 
 		code := codes.Ok
-		if res.IsError() {
+		switch {
+		case res.StatusCode() >= http.StatusInternalServerError:
+			code = codes.Error
+		case res.IsError() && cfg.span4xxNotError:
+			code = codes.Unset
+		case res.IsError():
 			code = codes.Error
 		}
 		span.SetStatus(code, res.Status())
@@ -78,14 +137,76 @@ func endTraceSuccess(cfg *config) resty.ResponseMiddleware {
 
 func endTraceError(cfg *config) resty.ErrorHook {
 	return func(req *resty.Request, err error) {
-		if !cfg.otelMWEnabled {
+		if !cfg.otelMWEnabled || telemetrySuppressed(req.Context()) {
 			return
 		}
 
-		span := trace.SpanFromContext(req.Context())
+		span, ok := requestSpan(req)
+		if !ok {
+			return
+		}
 		defer span.End()
-		span.SetAttributes(httpconv.ClientRequest(req.RawRequest)...)
+		if req.RawRequest != nil {
+			span.SetAttributes(httpconv.ClientRequest(req.RawRequest)...)
+		}
+		recordAttemptErrors(cfg, span, req.Context())
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 	}
 }
+
+const attemptKey = attribute.Key("httpz.attempt")
+
+// requestIDAttrKey is the span and log attribute of the [WithOutgoingRequestID]
+// request ID.
+const requestIDAttrKey = attribute.Key("httpz.request.id")
+
+type attemptErrorsCtxKey struct{}
+
+// attemptErrors are the errors of the retried attempts of a request, stored in
+// its context by the first one.
+type attemptErrors struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// addAttemptError keeps the error of an attempt about to be retried for
+// [recordAttemptErrors] when [WithTraceAllErrorsAsEvents] is set, a failed
+// response without an error is kept as a *[StatusError].
+func addAttemptError(cfg *config) resty.RetryHookFunc {
+	return func(res *resty.Response, err error) {
+		if !cfg.otelMWEnabled || !cfg.traceAttemptErrors || res == nil ||
+			telemetrySuppressed(res.Request.Context()) {
+			return
+		}
+
+		if err == nil {
+			err = &StatusError{StatusCode: res.StatusCode(), Status: res.Status()}
+		}
+		req := res.Request
+		errs, ok := req.Context().Value(attemptErrorsCtxKey{}).(*attemptErrors)
+		if !ok {
+			errs = &attemptErrors{}
+			req.SetContext(context.WithValue(req.Context(), attemptErrorsCtxKey{}, errs))
+		}
+		errs.mu.Lock()
+		errs.errs = append(errs.errs, err)
+		errs.mu.Unlock()
+	}
+}
+
+// recordAttemptErrors records the errors of the attempts before the one of span
+// as exception events with their attempt number, so the span ending the request
+// tells every failure of a flaky call.
+func recordAttemptErrors(cfg *config, span trace.Span, ctx context.Context) {
+	errs, ok := ctx.Value(attemptErrorsCtxKey{}).(*attemptErrors)
+	if !cfg.traceAttemptErrors || !ok {
+		return
+	}
+
+	errs.mu.Lock()
+	defer errs.mu.Unlock()
+	for i, err := range errs.errs {
+		span.RecordError(err, trace.WithAttributes(attemptKey.Int(i+1)))
+	}
+}
@@ -1,10 +1,16 @@
 package httpz
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/goccy/go-json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/attribute"
@@ -184,6 +190,164 @@ func TestOtelMiddleware(t *testing.T) {
 	})
 }
 
+func TestSpanDurationAttribute(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/otel/slow",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(20 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	client := NewClient("test-otel-client", server.URL,
+		WithPaths(map[string]string{"slow": "/test/otel/slow"}),
+		WithTracer(tp),
+		WithOtelMWEnabled(true),
+	)
+
+	_, err := client.NewRequest(context.Background()).Get(client.GetPath("slow"))
+
+	require.NoError(t, err)
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	var duration attribute.Value
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == semconv.HTTPClientRequestDurationName {
+			duration = attr.Value
+		}
+	}
+	require.Equal(t, attribute.FLOAT64, duration.Type())
+	assert.GreaterOrEqual(t, duration.AsFloat64(), 0.02)
+	assert.Less(t, duration.AsFloat64(), 1.0)
+}
+
+func TestPeerService(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/otel",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	paths := map[string]string{"otel": "/test/otel"}
+
+	tests := []struct {
+		name string
+		opts []option
+		want string
+	}{
+		{name: "defaults to client name", want: "test-otel-client"},
+		{name: "custom peer service", opts: []option{WithPeerService("payments-api")}, want: "payments-api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+			client := NewClient("test-otel-client", server.URL, append([]option{
+				WithPaths(paths),
+				WithTracer(tp),
+				WithOtelMWEnabled(true),
+			}, tt.opts...)...)
+
+			_, err := client.NewRequest(context.Background()).Get(client.GetPath("otel"))
+
+			require.NoError(t, err)
+			spans := rec.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.want, findStringAttribute(spans[0].Attributes(), semconv.PeerServiceKey))
+		})
+	}
+}
+
+func TestSpanErrorOn4xx(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/otel/not-found",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/otel/unavailable",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		},
+	)
+	paths := map[string]string{
+		"notFound":    "/test/otel/not-found",
+		"unavailable": "/test/otel/unavailable",
+	}
+
+	tests := []struct {
+		name     string
+		opts     []option
+		path     string
+		wantCode codes.Code
+	}{
+		{name: "404 is error by default", path: "notFound", wantCode: codes.Error},
+		{name: "404 is unset when disabled", opts: []option{WithSpanErrorOn4xx(false)}, path: "notFound", wantCode: codes.Unset},
+		{name: "503 is error when disabled", opts: []option{WithSpanErrorOn4xx(false)}, path: "unavailable", wantCode: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+			client := NewClient("test-otel-client", server.URL, append([]option{
+				WithPaths(paths),
+				WithTracer(tp),
+				WithOtelMWEnabled(true),
+			}, tt.opts...)...)
+
+			_, err := client.NewRequest(context.Background()).Get(client.GetPath(tt.path))
+
+			require.NoError(t, err)
+			spans := rec.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.wantCode, spans[0].Status().Code)
+		})
+	}
+}
+
+func TestOtelMiddlewareGlobalTracerFallback(t *testing.T) {
+	t.Run("logs fallback without tracer", func(t *testing.T) {
+		b := &bytes.Buffer{}
+
+		NewClient("test-otel-client", "http://localhost",
+			WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+			WithOtelMWEnabled(true),
+		)
+
+		assert.Contains(t, b.String(), `"level":"INFO","msg":"[HTTPZ] otel middleware enabled without a tracer provider, using the global one","client":"test-otel-client"`)
+	})
+
+	t.Run("no log with tracer", func(t *testing.T) {
+		b := &bytes.Buffer{}
+
+		NewClient("test-otel-client", "http://localhost",
+			WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+			WithTracer(sdktrace.NewTracerProvider()),
+			WithOtelMWEnabled(true),
+		)
+
+		assert.Empty(t, b.String())
+	})
+
+	t.Run("no log when disabled", func(t *testing.T) {
+		b := &bytes.Buffer{}
+
+		NewClient("test-otel-client", "http://localhost",
+			WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		)
+
+		assert.Empty(t, b.String())
+	})
+}
+
 func findIntAttribute(attrs []attribute.KeyValue, key attribute.Key) int {
 	for _, attr := range attrs {
 		if attr.Key == key {
@@ -201,3 +365,140 @@ func findStringAttribute(attrs []attribute.KeyValue, key attribute.Key) string {
 	}
 	return ""
 }
+
+func TestOtelMiddlewareDecodeSpan(t *testing.T) {
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	items := make([]item, 1000)
+	for i := range items {
+		items[i] = item{ID: i, Name: fmt.Sprintf("item-%d", i)}
+	}
+	body, err := json.Marshal(items)
+	require.NoError(t, err)
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/otel/items",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(body)
+		},
+	})
+	rec := tracetest.NewSpanRecorder()
+	client := NewClient("test-otel-client", server.URL,
+		WithPaths(map[string]string{"items": "/test/otel/items"}),
+		WithTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))),
+		WithOtelMWEnabled(true),
+	)
+	var result []item
+
+	_, err = client.NewRequest(context.Background()).
+		SetResult(&result).
+		Get(client.GetPath("items"))
+
+	require.NoError(t, err)
+	assert.Len(t, result, 1000)
+	spans := rec.Ended()
+	require.Len(t, spans, 2)
+	decode, request := spans[0], spans[1]
+	assert.Equal(t, "http.response.decode", decode.Name())
+	assert.Equal(t, "HTTP GET", request.Name())
+	assert.Equal(t, request.SpanContext().SpanID(), decode.Parent().SpanID())
+	assert.False(t, decode.StartTime().Before(request.StartTime()))
+	assert.False(t, decode.EndTime().After(request.EndTime()))
+}
+
+func TestTraceAllErrorsAsEvents(t *testing.T) {
+	attempts := 0
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/otel/flaky",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	rec := tracetest.NewSpanRecorder()
+	client := NewClient("test-otel-client", server.URL,
+		WithTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))),
+		WithOtelMWEnabled(true),
+		WithTraceAllErrorsAsEvents(true),
+	)
+	client.SetRetryCount(2).
+		SetRetryWaitTime(time.Millisecond).
+		SetRetryMaxWaitTime(time.Millisecond)
+
+	res, err := client.NewRequest(context.Background()).Get("/test/otel/flaky")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	spans := rec.Ended()
+	require.Len(t, spans, 3)
+	last := spans[len(spans)-1]
+	assert.Equal(t, codes.Ok, last.Status().Code)
+	events := last.Events()
+	require.Len(t, events, 2)
+	for i, event := range events {
+		assert.Equal(t, semconv.ExceptionEventName, event.Name)
+		assert.Contains(t, event.Attributes, attemptKey.Int(i+1))
+		assert.Contains(t, event.Attributes, semconv.ExceptionMessage("httpz: error response: 503 Service Unavailable"))
+	}
+}
+
+func TestOtelMiddlewareKeepsParentSpanOnThrottle(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/otel/throttled",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	client := NewClient("test-otel-client", server.URL,
+		WithTracer(tp),
+		WithOtelMWEnabled(true),
+	)
+	// warming up since now for an hour, every request is throttled
+	client.cfg.cbWarmup = &cbWarmup{duration: time.Hour, start: time.Now()}
+	ctx, parentSpan := tp.Tracer("test-tracer").Start(context.Background(), "parent-span")
+
+	_, err := client.NewRequest(ctx).Get("/test/otel/throttled")
+
+	require.ErrorIs(t, err, ErrCircuitBreakerWarmup)
+	assert.True(t, parentSpan.IsRecording(), "the parent span isn't ended")
+	assert.Empty(t, rec.Ended())
+	parentSpan.End()
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+}
+
+func TestOtelMiddlewareKeepsParentSpanWithoutTraceInterceptor(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	client := NewClient("test-otel-client", server.URL,
+		WithTracer(tp),
+		WithOtelMWEnabled(true),
+		WithClientInterceptorChain(Interceptor{Name: InterceptorLog}),
+	)
+	ctx, parentSpan := tp.Tracer("test-tracer").Start(context.Background(), "parent-span")
+
+	_, err := client.NewRequest(ctx).Get("/test/otel/unreachable")
+
+	require.Error(t, err)
+	assert.True(t, parentSpan.IsRecording(), "the parent span isn't ended")
+	assert.Empty(t, rec.Ended())
+	parentSpan.End()
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+}
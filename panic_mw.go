@@ -0,0 +1,61 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+	"resty.dev/v3"
+)
+
+// ErrMiddlewarePanic is wrapped by the error returned from the verb call when a
+// middleware of a [WithClientInterceptorChain] interceptor, or the built-in log
+// middleware, panics.
+var ErrMiddlewarePanic = errors.New("httpz: middleware panic")
+
+// recoverRequest wraps m so a panic is recorded on the request span, logged and
+// returned as an error wrapping [ErrMiddlewarePanic] instead of crashing the
+// caller.
+func recoverRequest(cfg *config, m resty.RequestMiddleware) resty.RequestMiddleware {
+	return func(c *resty.Client, req *resty.Request) (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = handlePanic(cfg, req.Context(), rec)
+			}
+		}()
+		return m(c, req)
+	}
+}
+
+// recoverResponse is the [resty.ResponseMiddleware] counterpart of
+// [recoverRequest].
+func recoverResponse(cfg *config, m resty.ResponseMiddleware) resty.ResponseMiddleware {
+	return func(c *resty.Client, res *resty.Response) (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = handlePanic(cfg, res.Request.Context(), rec)
+			}
+		}()
+		return m(c, res)
+	}
+}
+
+func handlePanic(cfg *config, ctx context.Context, rec any) error {
+	err := fmt.Errorf("%w: %v", ErrMiddlewarePanic, rec)
+	stack := string(debug.Stack())
+
+	trace.SpanFromContext(ctx).AddEvent("panic", trace.WithAttributes(
+		semconv.ExceptionMessage(err.Error()),
+		semconv.ExceptionStacktrace(stack),
+	))
+	cfg.logger.ErrorContext(ctx, "[HTTPZ][PANIC] recovered",
+		slog.Any("panic", rec),
+		slog.String(string(semconv.ExceptionStacktraceKey), stack),
+	)
+
+	return err
+}
@@ -0,0 +1,93 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"resty.dev/v3"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/panic",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+
+	t.Run("request middleware panic", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		rec := tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"panic": "/test/panic"}),
+			WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+			WithTracer(tp),
+			WithOtelMWEnabled(true),
+			WithClientInterceptorChain(append(DefaultInterceptorChain(), Interceptor{
+				Name: "panicking",
+				Request: func(_ *resty.Client, _ *resty.Request) error {
+					panic("boom")
+				},
+			})...),
+		)
+
+		var err error
+		assert.NotPanics(t, func() {
+			_, err = client.NewRequest(context.Background()).Get(client.GetPath("panic"))
+		})
+
+		require.ErrorIs(t, err, ErrMiddlewarePanic)
+		assert.ErrorContains(t, err, "boom")
+		assert.Contains(t, b.String(), `"level":"ERROR","msg":"[HTTPZ][PANIC] recovered","panic":"boom"`)
+
+		spans := rec.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		require.NotEmpty(t, spans[0].Events())
+		assert.Equal(t, "panic", spans[0].Events()[0].Name)
+	})
+
+	t.Run("response middleware panic", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"panic": "/test/panic"}),
+			WithClientInterceptorChain(Interceptor{
+				Name: "panicking",
+				Response: func(_ *resty.Client, _ *resty.Response) error {
+					panic("boom")
+				},
+			}),
+		)
+
+		var err error
+		assert.NotPanics(t, func() {
+			_, err = client.NewRequest(context.Background()).Get(client.GetPath("panic"))
+		})
+
+		require.ErrorIs(t, err, ErrMiddlewarePanic)
+	})
+
+	t.Run("log middleware panic", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"panic": "/test/panic"}),
+			WithLogMWEnabled(true),
+			WithRequestBodyLogFormatter(func(any) any { panic("boom") }),
+		)
+
+		var err error
+		assert.NotPanics(t, func() {
+			_, err = client.NewRequest(context.Background()).Get(client.GetPath("panic"))
+		})
+
+		require.ErrorIs(t, err, ErrMiddlewarePanic)
+	})
+}
@@ -0,0 +1,67 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type startupProbe struct {
+	pathName string
+	timeout  time.Duration
+}
+
+// probe sends a HEAD request to the startup probe path of every base URL, any
+// response proves the server is reachable, only a transport error fails it.
+func (c *Client) probe(p *startupProbe) error {
+	var path string
+	if p.pathName != "" {
+		c.cfg.pathsMu.RLock()
+		_, ok := c.cfg.paths[p.pathName]
+		c.cfg.pathsMu.RUnlock()
+		if !ok {
+			return fmt.Errorf("httpz: startup probe: unknown path name %q", p.pathName)
+		}
+		path = c.GetPath(p.pathName)
+	}
+	baseURLs := c.cfg.baseURLs
+	if len(baseURLs) == 0 {
+		baseURLs = []string{c.BaseURL()}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	var errs []error
+	for _, baseURL := range baseURLs {
+		if err := c.probeURL(ctx, strings.TrimRight(baseURL, "/")+path); err != nil {
+			errs = append(errs, fmt.Errorf("httpz: startup probe: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Client) probeURL(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.Client.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	return nil
+}
+
+// StartupError returns the error of the [WithStartupProbe] requests sent by
+// [NewClient], nil when they succeeded or no probe is configured. Use
+// [NewClientE] to fail the construction instead.
+func (c *Client) StartupError() error {
+	return c.startupErr
+}
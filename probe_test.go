@@ -0,0 +1,84 @@
+package httpz
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupProbe(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodHead,
+		path:   "/health",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+
+	t.Run("reachable", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"health": "/health"}),
+			WithStartupProbe("health", time.Second),
+		)
+
+		assert.NoError(t, client.StartupError())
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		client := NewClient("test-client", "http://127.0.0.1:1",
+			WithStartupProbe("", time.Second),
+		)
+
+		assert.ErrorContains(t, client.StartupError(), "httpz: startup probe")
+	})
+
+	t.Run("unknown path name", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithStartupProbe("health", time.Second),
+		)
+
+		assert.ErrorContains(t, client.StartupError(), `unknown path name "health"`)
+	})
+
+	t.Run("every base url", func(t *testing.T) {
+		client := NewClient("test-client", "",
+			WithPaths(map[string]string{"health": "/health"}),
+			WithBaseURLs([]string{server.URL, server.URL + "/"}),
+			WithStartupProbe("health", time.Second),
+		)
+		assert.NoError(t, client.StartupError())
+
+		client = NewClient("test-client", "",
+			WithPaths(map[string]string{"health": "/health"}),
+			WithBaseURLs([]string{server.URL, "http://127.0.0.1:1"}),
+			WithStartupProbe("health", time.Second),
+		)
+		assert.ErrorContains(t, client.StartupError(), "127.0.0.1:1")
+	})
+
+	t.Run("NewClientE fails construction", func(t *testing.T) {
+		client, err := NewClientE("test-client", "http://127.0.0.1:1",
+			WithStartupProbe("", time.Second),
+		)
+
+		assert.Nil(t, client)
+		assert.ErrorContains(t, err, "httpz: startup probe")
+
+		client, err = NewClientE("test-client", server.URL,
+			WithPaths(map[string]string{"health": "/health"}),
+			WithStartupProbe("health", time.Second),
+		)
+
+		require.NoError(t, err)
+		assert.NotNil(t, client)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client := NewClient("test-client", "http://127.0.0.1:1")
+
+		assert.NoError(t, client.StartupError())
+	})
+}
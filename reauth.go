@@ -0,0 +1,112 @@
+package httpz
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// reauthTransport refreshes the credentials and retries the request once when
+// the server responds with 401 Unauthorized to the client auth token. The
+// concurrent 401s share a single refresh.
+type reauthTransport struct {
+	next    http.RoundTripper
+	refresh func(ctx context.Context) error
+	client  *Client
+
+	mu sync.Mutex
+	// call is the refresh in progress, if any.
+	call *refreshCall
+	// stale is the "Authorization" header replaced by the last refresh.
+	stale string
+}
+
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+func (t *reauthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+	// the body was consumed by the first attempt and can't be replayed
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return res, nil
+	}
+
+	ctx := req.Context()
+	sent := req.Header.Get(t.client.HeaderAuthorizationKey())
+	t.mu.Lock()
+	stale := t.stale
+	t.mu.Unlock()
+	if sent == t.authHeader() {
+		if err := t.refreshOnce(ctx, sent); err != nil {
+			_ = res.Body.Close()
+			return nil, fmt.Errorf("httpz: reauth on 401: %w", err)
+		}
+	} else if sent == "" || sent != stale {
+		// a per-request token isn't the client's to refresh, only the one
+		// refreshed by a concurrent request since this one was sent is retried
+		return res, nil
+	}
+
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+
+	retryReq := req.Clone(ctx)
+	if req.GetBody != nil {
+		if retryReq.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("httpz: reauth on 401: %w", err)
+		}
+	}
+	if header := t.authHeader(); header != "" {
+		retryReq.Header.Set(t.client.HeaderAuthorizationKey(), header)
+	}
+
+	return t.next.RoundTrip(retryReq)
+}
+
+// refreshOnce calls refresh, or waits for the one in progress, recording the
+// "Authorization" header sent with the client token before it as stale.
+func (t *reauthTransport) refreshOnce(ctx context.Context, sent string) error {
+	t.mu.Lock()
+	if call := t.call; call != nil {
+		t.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	t.call = call
+	t.mu.Unlock()
+
+	call.err = t.refresh(ctx)
+
+	t.mu.Lock()
+	t.call = nil
+	if call.err == nil {
+		t.stale = sent
+	}
+	t.mu.Unlock()
+	close(call.done)
+
+	return call.err
+}
+
+// authHeader returns the "Authorization" header of the client auth token, or
+// "" when it isn't set.
+func (t *reauthTransport) authHeader() string {
+	token := t.client.AuthToken()
+	if token == "" {
+		return ""
+	}
+	return strings.TrimSpace(t.client.AuthScheme() + " " + token)
+}
@@ -0,0 +1,175 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReauthOn401(t *testing.T) {
+	var attempts int
+	var bodies []string
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodPost,
+			path:   "/test/reauth",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if r.Header.Get("Authorization") != "Bearer fresh-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/reauth/always",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+		},
+	)
+	paths := map[string]string{
+		"reauth":       "/test/reauth",
+		"reauthAlways": "/test/reauth/always",
+	}
+
+	t.Run("401 then 200 after refresh", func(t *testing.T) {
+		attempts, bodies = 0, nil
+		refreshes := 0
+		var client *Client
+		client = NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithAuthToken("expired-token"),
+			WithReauthOn401(func(ctx context.Context) error {
+				refreshes++
+				client.SetAuthToken("fresh-token")
+				return nil
+			}),
+		)
+
+		res, err := client.NewRequest(context.Background()).
+			SetBody(`{"name":"alice"}`).
+			Post(client.GetPath("reauth"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, 1, refreshes)
+		assert.Equal(t, 2, attempts)
+		assert.Equal(t, []string{`{"name":"alice"}`, `{"name":"alice"}`}, bodies)
+	})
+
+	t.Run("retry at most once", func(t *testing.T) {
+		attempts = 0
+		refreshes := 0
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithAuthToken("expired-token"),
+			WithReauthOn401(func(ctx context.Context) error {
+				refreshes++
+				return nil
+			}),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("reauthAlways"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+		assert.Equal(t, 1, refreshes)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("refresh error", func(t *testing.T) {
+		attempts = 0
+		wantErr := errors.New("refresh failed")
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithReauthOn401(func(ctx context.Context) error {
+				return wantErr
+			}),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("reauthAlways"))
+
+		assert.ErrorIs(t, err, wantErr)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("per-request token is not replaced", func(t *testing.T) {
+		attempts = 0
+		refreshes := 0
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithAuthToken("expired-token"),
+			WithReauthOn401(func(ctx context.Context) error {
+				refreshes++
+				return nil
+			}),
+		)
+
+		res, err := client.NewRequest(context.Background()).
+			SetAuthToken("per-request-token").
+			Get(client.GetPath("reauthAlways"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+		assert.Zero(t, refreshes)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+func TestReauthOn401Concurrent(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/reauth/concurrent",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer fresh-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	var refreshes atomic.Int32
+	var client *Client
+	client = NewClient("test-client", server.URL,
+		WithAuthToken("expired-token"),
+		WithReauthOn401(func(ctx context.Context) error {
+			refreshes.Add(1)
+			time.Sleep(50 * time.Millisecond)
+			client.SetAuthToken("fresh-token")
+			return nil
+		}),
+	)
+
+	var wg sync.WaitGroup
+	statuses := make([]int, 10)
+	for i := range statuses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := client.NewRequest(context.Background()).Get("/test/reauth/concurrent")
+			if assert.NoError(t, err) {
+				statuses[i] = res.StatusCode()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), refreshes.Load())
+	for _, status := range statuses {
+		assert.Equal(t, http.StatusOK, status)
+	}
+}
@@ -0,0 +1,19 @@
+package httpz
+
+import (
+	"net/http"
+
+	"resty.dev/v3"
+)
+
+// maxRedirectsPolicy stops following redirects after n of them, returning the
+// last redirect response (e.g. a 302 with its "Location" header) instead of an
+// error.
+func maxRedirectsPolicy(n int) resty.RedirectPolicy {
+	return resty.RedirectPolicyFunc(func(_ *http.Request, via []*http.Request) error {
+		if len(via) > n {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	})
+}
@@ -0,0 +1,87 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirects(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/redirect/1",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/test/redirect/2", http.StatusFound)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/redirect/2",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/test/redirect/target", http.StatusFound)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/redirect/target",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+	)
+	paths := map[string]string{"redirect": "/test/redirect/1"}
+
+	t.Run("follows redirects by default", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths))
+
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("redirect"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+	})
+
+	t.Run("max 0 returns the redirect response", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithMaxRedirects(0),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("redirect"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusFound, res.StatusCode())
+		assert.Equal(t, "/test/redirect/2", res.Header().Get("Location"))
+	})
+
+	t.Run("max 1 returns the second redirect response", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithMaxRedirects(1),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("redirect"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusFound, res.StatusCode())
+		assert.Equal(t, "/test/redirect/target", res.Header().Get("Location"))
+	})
+
+	t.Run("custom redirect policy", func(t *testing.T) {
+		errRedirect := errors.New("redirect not allowed")
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithRedirectPolicy(func(req *http.Request, via []*http.Request) error {
+				return errRedirect
+			}),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("redirect"))
+
+		require.ErrorIs(t, err, errRedirect)
+	})
+}
@@ -0,0 +1,171 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/goccy/go-json"
+	"go.opentelemetry.io/otel/trace"
+	"resty.dev/v3"
+)
+
+const octetStream = "application/octet-stream"
+
+// RawResponseBody returns the raw response body bytes, it's available even
+// after the body has been decoded into the [resty.Request.SetResult] value
+// when [WithCaptureRawResponse] is enabled, otherwise it's empty once decoded.
+func RawResponseBody(res *resty.Response) []byte {
+	if res == nil {
+		return nil
+	}
+	return res.Bytes()
+}
+
+// NullFields returns the sorted paths of the fields explicitly null in the JSON
+// object body of res, nested ones joined with ".", e.g. "address.city". The
+// decoded value can't tell {"name":null} from {}, NullFields returns ["name"]
+// for the former only. It needs the raw body, see [WithResponseNullHandling],
+// and returns nil for a body that isn't a JSON object.
+func NullFields(res *resty.Response) []string {
+	var body map[string]any
+	if err := json.Unmarshal(RawResponseBody(res), &body); err != nil {
+		return nil
+	}
+
+	var fields []string
+	var walk func(prefix string, obj map[string]any)
+	walk = func(prefix string, obj map[string]any) {
+		for k, v := range obj {
+			switch v := v.(type) {
+			case nil:
+				fields = append(fields, prefix+k)
+			case map[string]any:
+				walk(prefix+k+".", v)
+			}
+		}
+	}
+	walk("", body)
+	slices.Sort(fields)
+
+	return fields
+}
+
+// ResponseBodyReader returns a new reader over the buffered response body, so
+// each middleware can read it in full when [WithResponseBodyReplay] is set.
+func ResponseBodyReader(res *resty.Response) io.Reader {
+	return bytes.NewReader(RawResponseBody(res))
+}
+
+// storeResponseBody stores the response body in the request context for
+// [ResponseBodyFromContext] when [WithResponseBodyToContext] is set, so the
+// later middlewares and hooks, e.g. an audit trail, read it the same way
+// whatever the request decodes into. Streamed bodies aren't stored.
+func storeResponseBody(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		req := res.Request
+		if !cfg.resBodyToCtx || res.Err != nil || req.DoNotParseResponse ||
+			req.ForceResponseContentType == octetStream {
+			return nil
+		}
+
+		var body any
+		switch {
+		case res.IsSuccess() && req.Result != nil:
+			body = req.Result
+		case res.IsError() && req.Error != nil:
+			body = req.Error
+		default:
+			body = res.Bytes()
+		}
+		req.SetContext(context.WithValue(req.Context(), responseBodyKey, body))
+
+		return nil
+	}
+}
+
+// storeResponseDuration stores the request duration in the request context for
+// [ResponseDuration] when [WithResponseTimeRecording] is set.
+func storeResponseDuration(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		if !cfg.recordResTime || res.RawResponse == nil {
+			return nil
+		}
+
+		req := res.Request
+		req.SetContext(context.WithValue(req.Context(), responseDurationKey, res.Duration()))
+
+		return nil
+	}
+}
+
+// ResponseDuration returns the duration of the request of res recorded by
+// [WithResponseTimeRecording], from sending it to receiving the response, so
+// the callers building their own metrics don't measure it again. It reports
+// false when it wasn't recorded.
+func ResponseDuration(res *resty.Response) (time.Duration, bool) {
+	if res == nil || res.Request == nil {
+		return 0, false
+	}
+	d, ok := res.Request.Context().Value(responseDurationKey).(time.Duration)
+	return d, ok
+}
+
+// TraceID returns the trace ID of the request span of res, e.g. to echo it back
+// to the caller for debugging, or "" when the request isn't traced.
+func TraceID(res *resty.Response) string {
+	if res == nil || res.Request == nil {
+		return ""
+	}
+	if sc := trace.SpanContextFromContext(res.Request.Context()); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+// GetBytes sends a GET request to path and returns the raw response body, e.g.
+// for binary endpoints (images, PDFs) that shouldn't be JSON decoded. The log
+// middleware logs the body size instead of the content.
+func (c *Client) GetBytes(ctx context.Context, path string) ([]byte, *resty.Response, error) {
+	res, err := c.NewRequest(ctx).
+		SetHeader("Accept", "*/*").
+		SetForceResponseContentType(octetStream).
+		Get(path)
+	if err != nil {
+		return nil, res, err
+	}
+	return res.Bytes(), res, nil
+}
+
+// GetRaw sends a GET request to path and returns the JSON response body
+// undecoded, so it can be decoded later into a type picked from its content,
+// e.g. a discriminator field. The message is nil for an error response.
+func (c *Client) GetRaw(ctx context.Context, path string) (json.RawMessage, *resty.Response, error) {
+	var raw json.RawMessage
+	res, err := c.NewRequest(ctx).
+		SetResult(&raw).
+		Get(path)
+	if err != nil {
+		return nil, res, err
+	}
+	return raw, res, nil
+}
+
+// responseBodySize returns the "Content-Length" of res, or the number of bytes
+// read when it's unknown, e.g. a chunked response. It reports false when the
+// size can't be known without reading the body, e.g. with
+// [resty.Request.SetDoNotParseResponse], rather than a misleading zero.
+func responseBodySize(res *resty.Response) (int64, bool) {
+	if res.RawResponse == nil {
+		return 0, false
+	}
+	if n := res.RawResponse.ContentLength; n >= 0 {
+		return n, true
+	}
+	if res.Request.DoNotParseResponse {
+		return 0, false
+	}
+	return res.Size(), true
+}
@@ -0,0 +1,81 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// defaultOnResponseBytesLimit bounds the bytes given to [WithOnResponseBytes]
+// when [WithMaxResponseBodySize] isn't set.
+const defaultOnResponseBytesLimit = 1 << 20
+
+// onResponseBytesTransport copies up to limit bytes of every response body as
+// the caller reads it, and gives them to fn once the body is read to its end or
+// to limit, or closed, so a streamed body isn't held back.
+type onResponseBytesTransport struct {
+	next  http.RoundTripper
+	fn    func(ctx context.Context, b []byte)
+	limit int64
+}
+
+func (t *onResponseBytesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil || res.Body == nil || res.Body == http.NoBody {
+		return res, err
+	}
+
+	res.Body = &teeBody{ReadCloser: res.Body, ctx: req.Context(), fn: t.fn, limit: t.limit}
+
+	return res, nil
+}
+
+// teeBody copies the bytes read from a response body for [WithOnResponseBytes].
+type teeBody struct {
+	io.ReadCloser
+	ctx   context.Context
+	fn    func(ctx context.Context, b []byte)
+	limit int64
+
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	read bool
+	done bool
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.read = b.read || n > 0 || err == io.EOF
+	if left := b.limit - int64(b.buf.Len()); left > 0 && n > 0 {
+		b.buf.Write(p[:min(int64(n), left)])
+	}
+	if err == io.EOF || int64(b.buf.Len()) >= b.limit {
+		b.flush()
+	}
+	return n, err
+}
+
+// Close gives the bytes read so far to fn, a body closed unread, e.g. the one
+// of a retried attempt left undecoded, isn't given.
+func (b *teeBody) Close() error {
+	b.mu.Lock()
+	if b.read {
+		b.flush()
+	}
+	b.mu.Unlock()
+	return b.ReadCloser.Close()
+}
+
+// flush calls fn once, b.mu must be held.
+func (b *teeBody) flush() {
+	if b.done {
+		return
+	}
+	b.done = true
+	b.fn(b.ctx, b.buf.Bytes())
+}
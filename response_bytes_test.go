@@ -0,0 +1,108 @@
+package httpz
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOnResponseBytes(t *testing.T) {
+	type testRes struct {
+		Name string `json:"name"`
+	}
+	body := `{"name":"Alice"}`
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/bytes",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		},
+	})
+	type ctxKey struct{}
+
+	t.Run("receives the exact bytes", func(t *testing.T) {
+		var got []byte
+		var gotCtxVal any
+		client := NewClient("test-client", server.URL,
+			WithOnResponseBytes(func(ctx context.Context, b []byte) {
+				got = append([]byte(nil), b...)
+				gotCtxVal = ctx.Value(ctxKey{})
+			}),
+		)
+		result := &testRes{}
+
+		res, err := client.NewRequest(context.WithValue(context.Background(), ctxKey{}, "val")).
+			SetResult(result).
+			Get("/test/bytes")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, body, string(got))
+		assert.Equal(t, "val", gotCtxVal)
+		assert.Equal(t, &testRes{Name: "Alice"}, res.Result())
+	})
+
+	t.Run("bounded by max response body size", func(t *testing.T) {
+		var got []byte
+		client := NewClient("test-client", server.URL,
+			WithMaxResponseBodySize(4),
+			WithOnResponseBytes(func(_ context.Context, b []byte) {
+				got = append([]byte(nil), b...)
+			}),
+		)
+
+		res, _ := client.NewRequest(context.Background()).Get("/test/bytes")
+		_ = res.String()
+
+		assert.Equal(t, body[:4], string(got))
+	})
+
+	t.Run("does not hold back a streamed body", func(t *testing.T) {
+		release := make(chan struct{})
+		streamServer := startTestServer(t, testHandler{
+			method: http.MethodGet,
+			path:   "/test/bytes/stream",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("data: 1\n\n"))
+				w.(http.Flusher).Flush()
+				select {
+				case <-release:
+				case <-time.After(2 * time.Second):
+				}
+				_, _ = w.Write([]byte("data: 2\n\n"))
+			},
+		})
+		var got []byte
+		client := NewClient("test-client", streamServer.URL,
+			WithOnResponseBytes(func(_ context.Context, b []byte) {
+				got = append([]byte(nil), b...)
+			}),
+		)
+
+		start := time.Now()
+		res, err := client.NewRequest(context.Background()).
+			SetDoNotParseResponse(true).
+			Get("/test/bytes/stream")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Less(t, time.Since(start), time.Second)
+
+		first := make([]byte, len("data: 1\n\n"))
+		_, err = io.ReadFull(res.Body, first)
+		require.NoError(t, err)
+		assert.Equal(t, "data: 1\n\n", string(first))
+		assert.Nil(t, got)
+
+		close(release)
+		rest, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "data: 2\n\n", string(rest))
+		assert.Equal(t, "data: 1\n\ndata: 2\n\n", string(got))
+	})
+}
@@ -0,0 +1,68 @@
+package httpz
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/goccy/go-json"
+	"resty.dev/v3"
+)
+
+// keepResponseKeysBody keeps the body of the responses whose keys are checked
+// by [checkResponseKeys] readable after it's decoded, the decoded value drops
+// the keys unknown to its type.
+func keepResponseKeysBody(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if name, ok := requestPathName(req); ok && cfg.expectedResKeys[name] != nil {
+			req.SetResponseBodyUnlimitedReads(true)
+		}
+
+		return nil
+	}
+}
+
+// checkResponseKeys logs a warning when the top level keys of a successful
+// JSON object response differ from the [WithExpectedResponseKeys] ones, an
+// early sign of a backend contract change.
+func checkResponseKeys(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		name, ok := requestPathName(res.Request)
+		if !ok || !res.IsSuccess() {
+			return nil
+		}
+		expected := cfg.expectedResKeys[name]
+		if expected == nil {
+			return nil
+		}
+
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(res.Bytes(), &body); err != nil {
+			return nil
+		}
+
+		var unexpected, missing []string
+		for k := range body {
+			if !slices.Contains(expected, k) {
+				unexpected = append(unexpected, k)
+			}
+		}
+		for _, k := range expected {
+			if _, ok := body[k]; !ok {
+				missing = append(missing, k)
+			}
+		}
+		if len(unexpected) == 0 && len(missing) == 0 {
+			return nil
+		}
+
+		slices.Sort(unexpected)
+		ctx := res.Request.Context()
+		requestLogger(ctx, cfg).WarnContext(ctx, "[HTTPZ] response keys differ from the expected ones",
+			slog.String("path.name", name),
+			slog.Any("unexpected_keys", unexpected),
+			slog.Any("missing_keys", missing),
+		)
+
+		return nil
+	}
+}
@@ -0,0 +1,72 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectedResponseKeys(t *testing.T) {
+	type user struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/users/1",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id":"1","name":"alice","nickname":"al"}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/users/2",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id":"2","name":"bob"}`))
+			},
+		},
+	)
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"getUser": "/users/{id}"}),
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithExpectedResponseKeys("getUser", []string{"id", "name"}),
+	)
+
+	t.Run("unexpected key", func(t *testing.T) {
+		b.Reset()
+		result := &user{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetPathParam("id", "1").
+			SetResult(result).
+			Get(client.GetPath("getUser"))
+
+		require.NoError(t, err)
+		assert.Equal(t, &user{ID: "1", Name: "alice"}, result)
+		logs := b.String()
+		assert.Contains(t, logs, `"level":"WARN"`)
+		assert.Contains(t, logs, `"path.name":"getUser"`)
+		assert.Contains(t, logs, `"unexpected_keys":["nickname"]`)
+	})
+
+	t.Run("expected keys", func(t *testing.T) {
+		b.Reset()
+
+		_, err := client.NewRequest(context.Background()).
+			SetPathParam("id", "2").
+			SetResult(&user{}).
+			Get(client.GetPath("getUser"))
+
+		require.NoError(t, err)
+		assert.Empty(t, b.String())
+	})
+}
@@ -0,0 +1,375 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"resty.dev/v3"
+)
+
+func TestRawResponseBody(t *testing.T) {
+	type testRawRes struct {
+		ID string `json:"id"`
+	}
+	wantBody := `{"id":"abc-123","extra":"` + strings.Repeat("x", 4096) + `"}`
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/raw",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(wantBody))
+		},
+	})
+	paths := map[string]string{"raw": "/test/raw"}
+
+	t.Run("capture enabled", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithCaptureRawResponse(true),
+		)
+		result := &testRawRes{}
+
+		res, err := client.NewRequest(context.Background()).
+			SetResult(result).
+			Get(client.GetPath("raw"))
+
+		require.NoError(t, err)
+		assert.Equal(t, &testRawRes{ID: "abc-123"}, res.Result())
+		assert.Equal(t, wantBody, string(RawResponseBody(res)))
+	})
+
+	t.Run("capture disabled", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithPaths(paths))
+		result := &testRawRes{}
+
+		res, err := client.NewRequest(context.Background()).
+			SetResult(result).
+			Get(client.GetPath("raw"))
+
+		require.NoError(t, err)
+		assert.Equal(t, &testRawRes{ID: "abc-123"}, res.Result())
+		assert.Empty(t, RawResponseBody(res))
+	})
+
+	t.Run("capture exceeds max size", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithCaptureRawResponse(true),
+			WithMaxResponseBodySize(1024),
+		)
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(&testRawRes{}).
+			Get(client.GetPath("raw"))
+
+		assert.ErrorIs(t, err, resty.ErrReadExceedsThresholdLimit)
+	})
+
+	t.Run("nil response", func(t *testing.T) {
+		assert.Nil(t, RawResponseBody(nil))
+	})
+}
+
+func TestResponseBodyReplay(t *testing.T) {
+	type testReplayRes struct {
+		ID string `json:"id"`
+	}
+	wantBody := `{"id":"abc-123"}`
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/replay",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(wantBody))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/replay/large",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"id":"` + strings.Repeat("x", 4096) + `"}`))
+			},
+		},
+	)
+	paths := map[string]string{
+		"replay":      "/test/replay",
+		"replayLarge": "/test/replay/large",
+	}
+
+	t.Run("every middleware reads the full body", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithResponseBodyReplay(1<<20),
+		)
+		var reads []string
+		readBody := func(_ *resty.Client, res *resty.Response) error {
+			b, err := io.ReadAll(ResponseBodyReader(res))
+			reads = append(reads, string(b))
+			return err
+		}
+		client.AddResponseMiddleware(readBody)
+		client.AddResponseMiddleware(readBody)
+		result := &testReplayRes{}
+
+		_, err := client.NewRequest(context.Background()).
+			SetResult(result).
+			Get(client.GetPath("replay"))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{wantBody, wantBody}, reads)
+		assert.Equal(t, "abc-123", result.ID)
+	})
+
+	t.Run("body exceeds max size", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(paths),
+			WithResponseBodyReplay(1024),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("replayLarge"))
+
+		assert.ErrorIs(t, err, resty.ErrReadExceedsThresholdLimit)
+	})
+}
+
+func TestGetBytes(t *testing.T) {
+	// PNG signature followed by bytes that aren't valid UTF-8 nor JSON
+	wantBody := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n', 0x00, 0xff, 0xfe}
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/bytes",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			// a misconfigured server labelling a binary body as JSON
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(wantBody)
+		},
+	})
+	b := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"bytes": "/test/bytes"}),
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+	)
+
+	body, res, err := client.GetBytes(context.Background(), client.GetPath("bytes"))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Equal(t, wantBody, body)
+	assert.Contains(t, b.String(), `"http.response.body.size":11`)
+	assert.NotContains(t, b.String(), `"http.response.body":`)
+}
+
+func TestGetRaw(t *testing.T) {
+	type card struct {
+		Number string `json:"number"`
+	}
+	type wallet struct {
+		Provider string `json:"provider"`
+	}
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/raw/card",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"type":"card","number":"4242"}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/raw/wallet",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"type":"wallet","provider":"paypal"}`))
+			},
+		},
+	)
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{
+			"card":   "/test/raw/card",
+			"wallet": "/test/raw/wallet",
+		}),
+	)
+	decode := func(t *testing.T, raw []byte) any {
+		t.Helper()
+		var kind struct {
+			Type string `json:"type"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &kind))
+		switch kind.Type {
+		case "card":
+			v := &card{}
+			require.NoError(t, json.Unmarshal(raw, v))
+			return v
+		case "wallet":
+			v := &wallet{}
+			require.NoError(t, json.Unmarshal(raw, v))
+			return v
+		}
+		return nil
+	}
+
+	raw, res, err := client.GetRaw(context.Background(), client.GetPath("card"))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Equal(t, &card{Number: "4242"}, decode(t, raw))
+
+	raw, _, err = client.GetRaw(context.Background(), client.GetPath("wallet"))
+
+	require.NoError(t, err)
+	assert.Equal(t, &wallet{Provider: "paypal"}, decode(t, raw))
+}
+
+func TestResponseBodyToContext(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/body-ctx/user",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"name":"alice"}`))
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/body-ctx/text",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte("hello"))
+			},
+		},
+	)
+	client := NewClient("test-client", server.URL, WithResponseBodyToContext(true))
+
+	t.Run("decoded result", func(t *testing.T) {
+		var hooked any
+		client.OnSuccess(func(_ *resty.Client, res *resty.Response) {
+			hooked, _ = ResponseBodyFromContext(res.Request.Context())
+		})
+
+		res, err := client.R().SetResult(&user{}).Get("/test/body-ctx/user")
+		require.NoError(t, err)
+
+		body, ok := ResponseBodyFromContext(res.Request.Context())
+		require.True(t, ok)
+		assert.Equal(t, &user{Name: "alice"}, body)
+		assert.Same(t, res.Result(), body)
+		assert.Equal(t, body, hooked)
+	})
+
+	t.Run("raw bytes", func(t *testing.T) {
+		res, err := client.R().Get("/test/body-ctx/text")
+		require.NoError(t, err)
+
+		body, ok := ResponseBodyFromContext(res.Request.Context())
+		require.True(t, ok)
+		assert.Equal(t, []byte("hello"), body)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		res, err := NewClient("test-client", server.URL).R().Get("/test/body-ctx/text")
+		require.NoError(t, err)
+
+		_, ok := ResponseBodyFromContext(res.Request.Context())
+		assert.False(t, ok)
+	})
+}
+
+func TestResponseNullHandling(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	type user struct {
+		Name    *string  `json:"name"`
+		Address *address `json:"address"`
+	}
+	bodies := map[string]string{
+		"/test/null/explicit": `{"name":null,"address":{"city":null}}`,
+		"/test/null/absent":   `{"address":{}}`,
+	}
+	var handlers []testHandler
+	for path, body := range bodies {
+		handlers = append(handlers, testHandler{
+			method: http.MethodGet,
+			path:   path,
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(body))
+			},
+		})
+	}
+	server := startTestServer(t, handlers...)
+	client := NewClient("test-client", server.URL, WithResponseNullHandling(true))
+
+	explicit, err := client.NewRequest(context.Background()).SetResult(&user{}).Get("/test/null/explicit")
+	require.NoError(t, err)
+	absent, err := client.NewRequest(context.Background()).SetResult(&user{}).Get("/test/null/absent")
+	require.NoError(t, err)
+
+	assert.Equal(t, &user{Address: &address{}}, explicit.Result(), "null decodes into the zero value")
+	assert.Equal(t, explicit.Result(), absent.Result())
+	assert.Equal(t, []string{"address.city", "name"}, NullFields(explicit))
+	assert.Empty(t, NullFields(absent))
+
+	t.Run("disabled", func(t *testing.T) {
+		res, err := NewClient("test-client", server.URL).NewRequest(context.Background()).
+			SetResult(&user{}).
+			Get("/test/null/explicit")
+
+		require.NoError(t, err)
+		assert.Nil(t, NullFields(res))
+	})
+}
+
+func TestResponseTimeRecording(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/duration",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(10 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	client := NewClient("test-client", server.URL, WithResponseTimeRecording(true))
+
+	res, err := client.NewRequest(context.Background()).Get("/test/duration")
+
+	require.NoError(t, err)
+	d, ok := ResponseDuration(res)
+	require.True(t, ok)
+	assert.GreaterOrEqual(t, d, 10*time.Millisecond)
+	assert.Equal(t, res.Duration(), d)
+
+	t.Run("disabled", func(t *testing.T) {
+		res, err := NewClient("test-client", server.URL).NewRequest(context.Background()).Get("/test/duration")
+
+		require.NoError(t, err)
+		_, ok := ResponseDuration(res)
+		assert.False(t, ok)
+	})
+}
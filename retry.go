@@ -0,0 +1,166 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"resty.dev/v3"
+)
+
+type retryStartCtxKey struct{}
+
+// startRetryBudget stores the start time of the first attempt in the request
+// context, the elapsed time of the retries is measured from it.
+func startRetryBudget(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.maxRetryElapsed <= 0 {
+			return nil
+		}
+
+		ctx := req.Context()
+		if _, ok := ctx.Value(retryStartCtxKey{}).(time.Time); !ok {
+			req.SetContext(context.WithValue(ctx, retryStartCtxKey{}, time.Now()))
+		}
+
+		return nil
+	}
+}
+
+// retryWithinBudget is the retry condition replacing the resty default ones
+// when [WithMaxRetryElapsedTime] or [WithRetryBudget] is set: a retry the
+// default conditions allow is denied once the time budget has elapsed since
+// the first attempt or the client retry tokens are exhausted, before the retry
+// wait starts.
+func retryWithinBudget(cfg *config) resty.RetryConditionFunc {
+	return func(res *resty.Response, err error) bool {
+		if !retryDefaultCondition(res, err) {
+			return false
+		}
+
+		start, ok := res.Request.Context().Value(retryStartCtxKey{}).(time.Time)
+		if ok && time.Since(start) >= cfg.maxRetryElapsed {
+			return false
+		}
+
+		return cfg.retryTokens == nil || cfg.retryTokens.withdraw(time.Now())
+	}
+}
+
+var errTooManyRedirects = regexp.MustCompile(`stopped after \d+ redirects\z`)
+
+// retryDefaultCondition reproduces the resty default retry conditions: the
+// temporary transport errors, except the TLS, redirect, scheme and header
+// ones, and the 429, 5xx but 501, and missing status codes.
+func retryDefaultCondition(res *resty.Response, err error) bool {
+	if _, ok := err.(*tls.CertificateVerificationError); ok {
+		return false
+	}
+	if u, ok := err.(*url.Error); ok {
+		msg := u.Error()
+		if errTooManyRedirects.MatchString(msg) ||
+			strings.Contains(msg, "unsupported protocol scheme") ||
+			strings.Contains(msg, "invalid header") {
+			return false
+		}
+		return u.Temporary()
+	}
+	if res == nil {
+		return false
+	}
+
+	code := res.StatusCode()
+	return code == http.StatusTooManyRequests ||
+		code >= 500 && code != http.StatusNotImplemented ||
+		code == 0
+}
+
+// bufferReaderBody buffers a non-seekable [io.Reader] body of a request that
+// may be retried, resty rewinds seekable bodies before every retry but sends a
+// consumed reader as an empty body.
+func bufferReaderBody(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if !cfg.retryResetReader || req.RetryCount == 0 {
+			return nil
+		}
+		r, ok := req.Body.(io.Reader)
+		if !ok {
+			return nil
+		}
+		if _, ok := r.(io.Seeker); ok {
+			return nil
+		}
+
+		b, err := io.ReadAll(r)
+		if c, ok := r.(io.Closer); ok {
+			_ = c.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("httpz: buffer request body: %w", err)
+		}
+		req.SetBody(bytes.NewReader(b))
+
+		return nil
+	}
+}
+
+// retryTokenBucket is the client-wide retry budget of [WithRetryBudget]: every
+// request deposits ratio tokens and every retry withdraws one, beyond the
+// minPerSec retries always allowed per second.
+type retryTokenBucket struct {
+	mu        sync.Mutex
+	ratio     float64
+	minPerSec int
+	tokens    float64
+	second    int64
+	minUsed   int
+}
+
+func newRetryTokenBucket(ratio float64, minPerSec int) *retryTokenBucket {
+	return &retryTokenBucket{ratio: ratio, minPerSec: minPerSec}
+}
+
+// deposit credits a request, the tokens are capped to what the last 100
+// requests earned so an idle period can't fund a burst of retries.
+func (b *retryTokenBucket) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, max(100*b.ratio, 1))
+}
+
+// withdraw reports whether a retry is allowed, consuming its token.
+func (b *retryTokenBucket) withdraw(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if sec := now.Unix(); sec != b.second {
+		b.second, b.minUsed = sec, 0
+	}
+	if b.minUsed < b.minPerSec {
+		b.minUsed++
+		return true
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	return false
+}
+
+func depositRetryTokens(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.retryTokens != nil && req.Attempt == 1 {
+			cfg.retryTokens.deposit()
+		}
+
+		return nil
+	}
+}
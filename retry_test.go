@@ -0,0 +1,178 @@
+package httpz
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxRetryElapsedTime(t *testing.T) {
+	attempts := 0
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/retry/budget",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			time.Sleep(20 * time.Millisecond)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	})
+	newClient := func(opts ...option) *Client {
+		client := NewClient("test-client", server.URL, opts...)
+		client.SetRetryCount(10)
+		client.SetRetryWaitTime(time.Millisecond)
+		client.SetRetryMaxWaitTime(time.Millisecond)
+		return client
+	}
+
+	t.Run("stops after the budget", func(t *testing.T) {
+		attempts = 0
+		client := newClient(WithMaxRetryElapsedTime(50 * time.Millisecond))
+
+		res, err := client.NewRequest(context.Background()).Get("/test/retry/budget")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode())
+		assert.Less(t, attempts, 5)
+		assert.Equal(t, attempts, res.Request.Attempt)
+	})
+
+	t.Run("no retry wait after a transport error once the budget has elapsed", func(t *testing.T) {
+		var dials int
+		client := newClient(
+			WithMaxRetryElapsedTime(time.Nanosecond),
+			WithTransportWrapper(func(http.RoundTripper) http.RoundTripper {
+				return roundTripFunc(func(*http.Request) (*http.Response, error) {
+					dials++
+					return nil, temporaryError{}
+				})
+			}),
+		)
+		client.SetRetryWaitTime(time.Second)
+		client.SetRetryMaxWaitTime(time.Second)
+		start := time.Now()
+
+		_, err := client.NewRequest(context.Background()).Get("/test/retry/budget")
+
+		require.ErrorIs(t, err, temporaryError{})
+		assert.Equal(t, 1, dials)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		attempts = 0
+		client := newClient()
+
+		_, err := client.NewRequest(context.Background()).Get("/test/retry/budget")
+
+		require.NoError(t, err)
+		assert.Equal(t, 11, attempts)
+	})
+}
+
+// temporaryError is a transport error retried by the default retry conditions.
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "temporary failure" }
+func (temporaryError) Temporary() bool { return true }
+func (temporaryError) Timeout() bool   { return false }
+
+func TestRetryResetReader(t *testing.T) {
+	var bodies []string
+	server := startTestServer(t, testHandler{
+		method: http.MethodPost,
+		path:   "/test/retry/reader",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			if len(bodies) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"reader": "/test/retry/reader"}),
+		WithRetryIdempotentOnly(false),
+		WithRetryResetReader(true),
+	)
+	client.SetRetryCount(2).
+		SetRetryWaitTime(time.Millisecond).
+		SetRetryMaxWaitTime(time.Millisecond)
+	// a non-seekable reader, like a streamed body
+	body := io.MultiReader(strings.NewReader(`{"name":`), strings.NewReader(`"alice"}`))
+
+	res, err := client.NewRequest(context.Background()).
+		SetBody(body).
+		Post(client.GetPath("reader"))
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Equal(t, []string{`{"name":"alice"}`, `{"name":"alice"}`, `{"name":"alice"}`}, bodies)
+}
+
+func TestRetryBudget(t *testing.T) {
+	attempts := 0
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/retry/outage",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	})
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"outage": "/test/retry/outage"}),
+		WithRetryBudget(0.5, 0),
+	)
+	client.SetRetryCount(3).
+		SetRetryWaitTime(time.Millisecond).
+		SetRetryMaxWaitTime(time.Millisecond)
+	get := func() int {
+		attempts = 0
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("outage"))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode())
+		return attempts
+	}
+
+	assert.Equal(t, 1, get(), "half a token, no retry")
+	assert.Equal(t, 2, get(), "one token, one retry")
+	assert.Equal(t, 1, get(), "budget exhausted")
+
+	t.Run("no retry wait once exhausted", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"outage": "/test/retry/outage"}),
+			WithRetryBudget(0, 0),
+		)
+		client.SetRetryCount(3).
+			SetRetryWaitTime(time.Second).
+			SetRetryMaxWaitTime(time.Second)
+		attempts = 0
+		start := time.Now()
+
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("outage"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode())
+		assert.Equal(t, 1, attempts)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+}
+
+func TestRetryTokenBucketMinPerSec(t *testing.T) {
+	b := newRetryTokenBucket(0, 2)
+	now := time.Unix(1000, 0)
+
+	assert.True(t, b.withdraw(now))
+	assert.True(t, b.withdraw(now.Add(500*time.Millisecond)))
+	assert.False(t, b.withdraw(now.Add(900*time.Millisecond)))
+	assert.True(t, b.withdraw(now.Add(time.Second)))
+}
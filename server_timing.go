@@ -0,0 +1,99 @@
+package httpz
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"resty.dev/v3"
+)
+
+// ServerTiming is a metric of the "Server-Timing" response header, e.g.
+// `db;dur=53.2;desc="Primary DB"`.
+type ServerTiming struct {
+	Name        string
+	Duration    time.Duration
+	Description string
+}
+
+// ParseServerTiming parses the metrics of "Server-Timing" header values,
+// metrics without a name are skipped and an invalid "dur" is ignored.
+func ParseServerTiming(values ...string) []ServerTiming {
+	var timings []ServerTiming
+	for _, v := range values {
+		for _, metric := range splitUnquoted(v, ',') {
+			params := splitUnquoted(metric, ';')
+			timing := ServerTiming{Name: strings.TrimSpace(params[0])}
+			if timing.Name == "" {
+				continue
+			}
+			for _, p := range params[1:] {
+				key, val, _ := strings.Cut(p, "=")
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "dur":
+					if ms, err := strconv.ParseFloat(val, 64); err == nil {
+						timing.Duration = time.Duration(ms * float64(time.Millisecond))
+					}
+				case "desc":
+					timing.Description = val
+				}
+			}
+			timings = append(timings, timing)
+		}
+	}
+	return timings
+}
+
+// splitUnquoted splits s around sep outside of double quoted strings.
+func splitUnquoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := range len(s) {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// recordServerTiming adds a span event per "Server-Timing" metric, so client
+// traces show the server side breakdown.
+func recordServerTiming(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		if !cfg.serverTimingEnabled || !cfg.otelMWEnabled {
+			return nil
+		}
+
+		span := trace.SpanFromContext(res.Request.Context())
+		for _, timing := range ParseServerTiming(res.Header().Values("Server-Timing")...) {
+			span.AddEvent("server-timing", trace.WithAttributes(
+				attribute.String("server_timing.name", timing.Name),
+				attribute.Float64("server_timing.duration", timing.Duration.Seconds()),
+				attribute.String("server_timing.description", timing.Description),
+			))
+		}
+
+		return nil
+	}
+}
+
+// serverTimingLogAttr returns the "Server-Timing" metrics as a log group of
+// durations by name.
+func serverTimingLogAttr(res *resty.Response) slog.Attr {
+	timings := ParseServerTiming(res.Header().Values("Server-Timing")...)
+	attrs := make([]any, 0, len(timings))
+	for _, timing := range timings {
+		attrs = append(attrs, slog.Duration(timing.Name, timing.Duration))
+	}
+	return slog.Group("http.response.server_timing", attrs...)
+}
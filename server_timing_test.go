@@ -0,0 +1,90 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestParseServerTiming(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []ServerTiming
+	}{
+		{
+			name:   "two metrics",
+			values: []string{`cache;desc="Cache Read";dur=23.2, db;dur=53`},
+			want: []ServerTiming{
+				{Name: "cache", Duration: 23200 * time.Microsecond, Description: "Cache Read"},
+				{Name: "db", Duration: 53 * time.Millisecond},
+			},
+		},
+		{
+			name:   "multiple header values and quoted separators",
+			values: []string{`app;desc="a, b; c"`, `total;dur=1`},
+			want: []ServerTiming{
+				{Name: "app", Description: "a, b; c"},
+				{Name: "total", Duration: time.Millisecond},
+			},
+		},
+		{
+			name:   "invalid duration and empty metric",
+			values: []string{`db;dur=abc, ,`},
+			want:   []ServerTiming{{Name: "db"}},
+		},
+		{
+			name: "no header",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseServerTiming(tt.values...))
+		})
+	}
+}
+
+func TestServerTiming(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/server-timing",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Server-Timing", `cache;desc="Cache Read";dur=23.2, db;dur=53`)
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	b := &bytes.Buffer{}
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"serverTiming": "/test/server-timing"}),
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+		WithTracer(tp),
+		WithOtelMWEnabled(true),
+		WithServerTimingEnabled(true),
+	)
+
+	_, err := client.NewRequest(context.Background()).Get(client.GetPath("serverTiming"))
+
+	require.NoError(t, err)
+	assert.Contains(t, b.String(), `"http.response.server_timing":{"cache":23200000,"db":53000000}`)
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	events := spans[0].Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "server-timing", events[0].Name)
+	assert.Contains(t, events[0].Attributes, attribute.String("server_timing.name", "cache"))
+	assert.Contains(t, events[0].Attributes, attribute.String("server_timing.description", "Cache Read"))
+	assert.Contains(t, events[1].Attributes, attribute.Float64("server_timing.duration", 0.053))
+}
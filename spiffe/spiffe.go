@@ -0,0 +1,40 @@
+// Package spiffe enables SPIFFE mutual TLS on an httpz client, keeping the
+// go-spiffe dependency out of the core package:
+//
+//	client := httpz.NewClient("payments", baseURL,
+//		httpz.WithTLSConfigHook(spiffe.TLSConfigHook(source, tlsconfig.AuthorizeID(serverID))),
+//	)
+package spiffe
+
+import (
+	"crypto/tls"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+)
+
+// Source provides the X.509 SVID presented to the server and the trust
+// bundles used to verify the server SVID, e.g. *workloadapi.X509Source.
+type Source interface {
+	x509svid.Source
+	x509bundle.Source
+}
+
+// TLSConfigHook returns the hook of httpz.WithTLSConfigHook enabling mutual
+// TLS using the X.509 SVIDs from source. The server SVID is verified against
+// the source trust bundles and its SPIFFE ID is checked by authorizer. It
+// returns nil when source is nil, leaving the TLS config untouched.
+//
+// default authorizer: [tlsconfig.AuthorizeAny]
+func TLSConfigHook(source Source, authorizer tlsconfig.Authorizer) func(*tls.Config) {
+	if source == nil {
+		return nil
+	}
+	if authorizer == nil {
+		authorizer = tlsconfig.AuthorizeAny()
+	}
+	return func(c *tls.Config) {
+		tlsconfig.HookMTLSClientConfig(c, source, source, authorizer)
+	}
+}
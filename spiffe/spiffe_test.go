@@ -0,0 +1,139 @@
+package spiffe
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/unlimited-budget-ecommerce/httpz"
+)
+
+type fakeSource struct {
+	svid   *x509svid.SVID
+	bundle *x509bundle.Bundle
+}
+
+func (s fakeSource) GetX509SVID() (*x509svid.SVID, error) {
+	return s.svid, nil
+}
+
+func (s fakeSource) GetX509BundleForTrustDomain(td spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	return s.bundle.GetX509BundleForTrustDomain(td)
+}
+
+func newTestCert(t *testing.T, tmpl, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func newTestSVID(t *testing.T, id spiffeid.ID, serial int64, ca *x509.Certificate, caKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+	return newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		URIs:         []*url.URL{id.URL()},
+	}, ca, caKey)
+}
+
+func TestTLSConfigHook(t *testing.T) {
+	td := spiffeid.RequireTrustDomainFromString("example.org")
+	serverID := spiffeid.RequireFromPath(td, "/payments")
+	clientID := spiffeid.RequireFromPath(td, "/orders")
+	ca, caKey := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		URIs:                  []*url.URL{td.ID().URL()},
+	}, nil, nil)
+	serverCert, serverKey := newTestSVID(t, serverID, 2, ca, caKey)
+	clientCert, clientKey := newTestSVID(t, clientID, 3, ca, caKey)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	var gotClientURI string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotClientURI = r.TLS.PeerCertificates[0].URIs[0].String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	source := fakeSource{
+		svid:   &x509svid.SVID{ID: clientID, Certificates: []*x509.Certificate{clientCert}, PrivateKey: clientKey},
+		bundle: x509bundle.FromX509Authorities(td, []*x509.Certificate{ca}),
+	}
+
+	t.Run("authorized server id", func(t *testing.T) {
+		client := httpz.NewClient("test-client", server.URL,
+			httpz.WithTLSConfigHook(TLSConfigHook(source, tlsconfig.AuthorizeID(serverID))),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get("/")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, clientID.String(), gotClientURI)
+	})
+
+	t.Run("unauthorized server id", func(t *testing.T) {
+		client := httpz.NewClient("test-client", server.URL,
+			httpz.WithTLSConfigHook(TLSConfigHook(source, tlsconfig.AuthorizeID(spiffeid.RequireFromPath(td, "/other")))),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get("/")
+
+		require.Error(t, err)
+	})
+
+	t.Run("untrusted server", func(t *testing.T) {
+		otherTD := spiffeid.RequireTrustDomainFromString("other.org")
+		client := httpz.NewClient("test-client", server.URL,
+			httpz.WithTLSConfigHook(TLSConfigHook(fakeSource{
+				svid:   source.svid,
+				bundle: x509bundle.FromX509Authorities(otherTD, []*x509.Certificate{ca}),
+			}, nil)),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get("/")
+
+		require.Error(t, err)
+	})
+}
+
+func TestTLSConfigHookNilSource(t *testing.T) {
+	assert.Nil(t, TLSConfigHook(nil, nil))
+}
@@ -0,0 +1,15 @@
+package httpz
+
+import "resty.dev/v3"
+
+// runStatusHandlers calls the handlers registered with [WithOnStatus] for the
+// response status code.
+func runStatusHandlers(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		for _, fn := range cfg.statusHandlers[res.StatusCode()] {
+			fn(res)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package httpz
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"resty.dev/v3"
+)
+
+func TestOnStatus(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/ok",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/limited",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "3")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+		},
+	)
+	var retryAfters []string
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{
+			"ok":      "/test/ok",
+			"limited": "/test/limited",
+		}),
+		WithOnStatus(http.StatusTooManyRequests, func(res *resty.Response) {
+			retryAfters = append(retryAfters, res.Header().Get("Retry-After"))
+		}),
+	)
+
+	_, err := client.NewRequest(context.Background()).Get(client.GetPath("ok"))
+	require.NoError(t, err)
+
+	assert.Empty(t, retryAfters)
+
+	_, err = client.NewRequest(context.Background()).Get(client.GetPath("limited"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"3"}, retryAfters)
+}
@@ -0,0 +1,212 @@
+package httpz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"resty.dev/v3"
+)
+
+// ErrRequestTimeout is returned from the verb call, when
+// [WithRequestContextTimeoutError] is enabled, if the request deadline is
+// exceeded while sending the request, reading the response or waiting for a
+// retry. It wraps [context.DeadlineExceeded], so both can be checked with
+// [errors.Is].
+var ErrRequestTimeout = fmt.Errorf("httpz: request timeout: %w", context.DeadlineExceeded)
+
+func mapTimeoutError(err error) error {
+	if err == nil || errors.Is(err, ErrRequestTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrRequestTimeout, err)
+}
+
+// timeoutContext reports an exceeded deadline as [ErrRequestTimeout], which
+// resty returns as is when the deadline is exceeded waiting for a retry.
+type timeoutContext struct {
+	context.Context
+}
+
+func (c timeoutContext) Err() error {
+	return mapTimeoutError(c.Context.Err())
+}
+
+func setTimeoutContext(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if !cfg.mapTimeoutErr {
+			return nil
+		}
+		if _, ok := req.Context().(timeoutContext); !ok {
+			req.SetContext(timeoutContext{req.Context()})
+		}
+
+		return nil
+	}
+}
+
+// setRequestTimeout sets the [WithRequestTimeout] timeout of the request.
+func setRequestTimeout() resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if d, ok := requestTimeoutFromContext(req.Context()); ok {
+			req.SetTimeout(d)
+		}
+
+		return nil
+	}
+}
+
+// setPathTimeout sets the [WithPathTimeouts] timeout of the target path name,
+// unless the request sets its own with [WithRequestTimeout].
+func setPathTimeout(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if len(cfg.pathTimeouts) == 0 {
+			return nil
+		}
+		if _, ok := requestTimeoutFromContext(req.Context()); ok {
+			return nil
+		}
+
+		if d, ok := pathTimeout(cfg, req); ok {
+			req.SetTimeout(d)
+		}
+
+		return nil
+	}
+}
+
+// pathTimeout returns the [WithPathTimeouts] timeout of the path name of req.
+func pathTimeout(cfg *config, req *resty.Request) (time.Duration, bool) {
+	name, ok := lookupPathName(cfg, req.URL)
+	if !ok {
+		return 0, false
+	}
+	d := cfg.pathTimeouts[name]
+	return d, d > 0
+}
+
+// setMethodTimeout sets the [WithMethodTimeouts] timeout of the request method,
+// unless the request sets its own with [WithRequestTimeout] or its path name
+// has a [WithPathTimeouts] one.
+func setMethodTimeout(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if len(cfg.methodTimeouts) == 0 {
+			return nil
+		}
+		if _, ok := requestTimeoutFromContext(req.Context()); ok {
+			return nil
+		}
+		if _, ok := pathTimeout(cfg, req); ok {
+			return nil
+		}
+
+		if d := cfg.methodTimeouts[req.Method]; d > 0 {
+			req.SetTimeout(d)
+		}
+
+		return nil
+	}
+}
+
+// mapTimeoutResponse maps the error of a response whose body couldn't be read
+// or decoded because the deadline was exceeded, the decoder reports it as a
+// syntax error.
+func mapTimeoutResponse(cfg *config) resty.ResponseMiddleware {
+	return func(_ *resty.Client, res *resty.Response) error {
+		if !cfg.mapTimeoutErr || res.Err == nil || errors.Is(res.Err, ErrRequestTimeout) {
+			return nil
+		}
+		if errors.Is(res.Request.Context().Err(), context.DeadlineExceeded) {
+			res.Err = fmt.Errorf("%w: %w", ErrRequestTimeout, res.Err)
+		}
+
+		return nil
+	}
+}
+
+// timeoutTransport maps the deadline errors of the round trip and of the
+// response body reads to [ErrRequestTimeout].
+type timeoutTransport struct {
+	next http.RoundTripper
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, mapTimeoutError(err)
+	}
+	if res.Body != nil && res.Body != http.NoBody {
+		res.Body = &timeoutBody{ReadCloser: res.Body}
+	}
+
+	return res, nil
+}
+
+type timeoutBody struct {
+	io.ReadCloser
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	return n, mapTimeoutError(err)
+}
+
+// ErrResponseIdleTimeout is returned reading a response body which received no
+// bytes for the [WithResponseIdleReadTimeout] duration.
+var ErrResponseIdleTimeout = errors.New("httpz: response idle read timeout")
+
+// idleTimeoutTransport cancels a request whose response body received no bytes
+// for timeout, see [WithResponseIdleReadTimeout].
+type idleTimeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *idleTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	res, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil || res.Body == nil || res.Body == http.NoBody {
+		cancel()
+		return res, err
+	}
+
+	body := &idleTimeoutBody{ReadCloser: res.Body, timeout: t.timeout, cancel: cancel}
+	body.timer = time.AfterFunc(t.timeout, func() {
+		body.timedOut.Store(true)
+		cancel()
+	})
+	res.Body = body
+
+	return res, nil
+}
+
+// idleTimeoutBody cancels the request when no read returns bytes for timeout,
+// its timer being reset on every read that does.
+type idleTimeoutBody struct {
+	io.ReadCloser
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+	cancel   context.CancelFunc
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.timer.Stop() {
+		b.timer.Reset(b.timeout)
+	}
+	if err != nil && err != io.EOF && b.timedOut.Load() {
+		err = fmt.Errorf("%w: %w", ErrResponseIdleTimeout, err)
+	}
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
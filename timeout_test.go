@@ -0,0 +1,265 @@
+package httpz
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	server := startTestServer(t,
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/slow-header",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/unavailable",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		},
+		testHandler{
+			method: http.MethodGet,
+			path:   "/test/slow-body",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"status":`))
+				w.(http.Flusher).Flush()
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			},
+		},
+	)
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{
+			"slowHeader": "/test/slow-header",
+			"slowBody":   "/test/slow-body",
+		}),
+		WithRequestContextTimeoutError(true),
+	)
+
+	for _, pathName := range []string{"slowHeader", "slowBody"} {
+		t.Run(pathName, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			_, err := client.NewRequest(ctx).
+				SetResult(&map[string]any{}).
+				Get(client.GetPath(pathName))
+
+			require.ErrorIs(t, err, ErrRequestTimeout)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		})
+	}
+
+	t.Run("with retries", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithRequestContextTimeoutError(true))
+		client.SetRetryCount(2)
+		client.SetRetryWaitTime(time.Millisecond)
+		client.SetRetryMaxWaitTime(time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.NewRequest(ctx).Get("/test/slow-header")
+
+		assert.ErrorIs(t, err, ErrRequestTimeout)
+	})
+
+	t.Run("during retry wait", func(t *testing.T) {
+		client := NewClient("test-client", server.URL, WithRequestContextTimeoutError(true))
+		client.SetRetryCount(2)
+		client.SetRetryWaitTime(time.Second)
+		client.SetRetryMaxWaitTime(time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.NewRequest(ctx).Get("/test/unavailable")
+
+		assert.ErrorIs(t, err, ErrRequestTimeout)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client := NewClient("test-client", server.URL)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.NewRequest(ctx).Get("/test/slow-header")
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NotErrorIs(t, err, ErrRequestTimeout)
+	})
+}
+
+func TestPathTimeouts(t *testing.T) {
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	server := startTestServer(t,
+		testHandler{method: http.MethodGet, path: "/test/report", handlerFunc: slow},
+		testHandler{method: http.MethodGet, path: "/test/lookup", handlerFunc: slow},
+	)
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{
+			"report": "/test/report",
+			"lookup": "/test/lookup",
+		}),
+		WithPathTimeouts(map[string]time.Duration{"report": time.Second}),
+	)
+	client.SetTimeout(20 * time.Millisecond)
+
+	t.Run("path timeout overrides client timeout", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("report"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+	})
+
+	t.Run("client timeout", func(t *testing.T) {
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("lookup"))
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("request timeout overrides path timeout", func(t *testing.T) {
+		ctx := WithRequestTimeout(context.Background(), 10*time.Millisecond)
+
+		_, err := client.NewRequest(ctx).Get(client.GetPath("report"))
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("request timeout equal to client timeout overrides path timeout", func(t *testing.T) {
+		ctx := WithRequestTimeout(context.Background(), client.Timeout())
+
+		_, err := client.NewRequest(ctx).Get(client.GetPath("report"))
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestResponseIdleReadTimeout(t *testing.T) {
+	stream := func(pause time.Duration) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			for range 5 {
+				_, _ = w.Write([]byte("chunk"))
+				w.(http.Flusher).Flush()
+				select {
+				case <-time.After(pause):
+				case <-r.Context().Done():
+					return
+				}
+			}
+		}
+	}
+	server := startTestServer(t,
+		testHandler{
+			method:      http.MethodGet,
+			path:        "/test/stream/steady",
+			handlerFunc: stream(20 * time.Millisecond),
+		},
+		testHandler{
+			method:      http.MethodGet,
+			path:        "/test/stream/stalled",
+			handlerFunc: stream(time.Second),
+		},
+	)
+	client := NewClient("test-client", server.URL, WithResponseIdleReadTimeout(60*time.Millisecond))
+
+	t.Run("steady stream", func(t *testing.T) {
+		body, _, err := client.GetBytes(context.Background(), "/test/stream/steady")
+
+		require.NoError(t, err)
+		assert.Equal(t, "chunkchunkchunkchunkchunk", string(body))
+	})
+
+	t.Run("stalled stream", func(t *testing.T) {
+		start := time.Now()
+		_, _, err := client.GetBytes(context.Background(), "/test/stream/stalled")
+
+		require.ErrorIs(t, err, ErrResponseIdleTimeout)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+}
+
+func TestMethodTimeouts(t *testing.T) {
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	server := startTestServer(t,
+		testHandler{method: http.MethodGet, path: "/test/orders/list", handlerFunc: slow},
+		testHandler{method: http.MethodPost, path: "/test/orders/create", handlerFunc: slow},
+		testHandler{method: http.MethodGet, path: "/test/report", handlerFunc: slow},
+	)
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"report": "/test/report"}),
+		WithPathTimeouts(map[string]time.Duration{"report": time.Second}),
+		WithMethodTimeouts(map[string]time.Duration{
+			http.MethodGet:  20 * time.Millisecond,
+			http.MethodPost: time.Second,
+		}),
+	)
+
+	t.Run("GET timeout", func(t *testing.T) {
+		_, err := client.NewRequest(context.Background()).Get("/test/orders/list")
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("POST timeout", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).Post("/test/orders/create")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+	})
+
+	t.Run("path timeout overrides method timeout", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).Get(client.GetPath("report"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+	})
+
+	t.Run("request timeout overrides method timeout", func(t *testing.T) {
+		ctx := WithRequestTimeout(context.Background(), 10*time.Millisecond)
+
+		_, err := client.NewRequest(ctx).Post("/test/orders/create")
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("path timeout equal to client timeout overrides method timeout", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithPaths(map[string]string{"report": "/test/report"}),
+			WithPathTimeouts(map[string]time.Duration{"report": 20 * time.Millisecond}),
+			WithMethodTimeouts(map[string]time.Duration{http.MethodGet: time.Second}),
+		)
+		client.SetTimeout(20 * time.Millisecond)
+
+		_, err := client.NewRequest(context.Background()).Get(client.GetPath("report"))
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,42 @@
+package httpz
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// applyTLSConfig applies the TLS related options to the TLS config of t.
+func applyTLSConfig(cfg *config, t *http.Transport) {
+	if cfg.tlsMinVersion == 0 && len(cfg.cipherSuites) == 0 && cfg.tlsConfigHook == nil && cfg.certReloader == nil {
+		return
+	}
+
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	if cfg.tlsConfigHook != nil {
+		cfg.tlsConfigHook(t.TLSClientConfig)
+	}
+	if cfg.certReloader != nil {
+		t.TLSClientConfig.GetClientCertificate = reloadClientCert(cfg.certReloader)
+	}
+	if cfg.tlsMinVersion > 0 {
+		t.TLSClientConfig.MinVersion = cfg.tlsMinVersion
+	}
+	if len(cfg.cipherSuites) > 0 {
+		t.TLSClientConfig.CipherSuites = cfg.cipherSuites
+	}
+}
+
+// reloadClientCert fetches the client certificate from reload on every
+// handshake, so a rotated certificate is used by the next connection.
+func reloadClientCert(reload func() (*tls.Certificate, error)) func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		cert, err := reload()
+		if err != nil {
+			return nil, fmt.Errorf("httpz: reload client certificate: %w", err)
+		}
+		return cert, nil
+	}
+}
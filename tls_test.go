@@ -0,0 +1,189 @@
+package httpz
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startTestTLSServer(t *testing.T, tlsConfig *tls.Config) (*httptest.Server, *http.Transport) {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = tlsConfig
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	transport := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+
+	return server, transport
+}
+
+func TestTLSMinVersion(t *testing.T) {
+	t.Run("tls 1.1 server rejected with min tls 1.2", func(t *testing.T) {
+		server, transport := startTestTLSServer(t, &tls.Config{
+			MinVersion: tls.VersionTLS10,
+			MaxVersion: tls.VersionTLS11,
+		})
+		client := NewClient("test-client", server.URL,
+			WithTransport(transport),
+			WithTLSMinVersion(tls.VersionTLS12),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get("/")
+
+		require.Error(t, err)
+	})
+
+	t.Run("tls 1.2 server accepted without min version", func(t *testing.T) {
+		server, transport := startTestTLSServer(t, &tls.Config{MaxVersion: tls.VersionTLS12})
+		client := NewClient("test-client", server.URL, WithTransport(transport))
+
+		res, err := client.NewRequest(context.Background()).Get("/")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+	})
+
+	t.Run("tls 1.2 server rejected with min tls 1.3", func(t *testing.T) {
+		server, transport := startTestTLSServer(t, &tls.Config{MaxVersion: tls.VersionTLS12})
+		client := NewClient("test-client", server.URL,
+			WithTransport(transport),
+			WithTLSMinVersion(tls.VersionTLS13),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get("/")
+
+		require.Error(t, err)
+		assert.Zero(t, transport.TLSClientConfig.MinVersion, "user transport must not be mutated")
+	})
+}
+
+func TestCipherSuites(t *testing.T) {
+	server, transport := startTestTLSServer(t, &tls.Config{
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+	})
+
+	t.Run("matching cipher suite", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithTransport(transport),
+			WithCipherSuites([]uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get("/")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+	})
+
+	t.Run("mismatching cipher suite", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithTransport(transport),
+			WithCipherSuites([]uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get("/")
+
+		require.Error(t, err)
+	})
+}
+
+func newTestCert(t *testing.T, tmpl, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func TestClientCertReloader(t *testing.T) {
+	ca, caKey := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+	newClientCert := func(serial int64) *tls.Certificate {
+		cert, key := newTestCert(t, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, ca, caKey)
+		return &tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key}
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	var gotSerial int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSerial = r.TLS.PeerCertificates[0].SerialNumber.Int64()
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	current, reloads := newClientCert(2), 0
+	client := NewClient("test-client", server.URL,
+		WithTransport(&http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}),
+		WithClientCertReloader(func() (*tls.Certificate, error) {
+			reloads++
+			return current, nil
+		}),
+	)
+
+	res, err := client.NewRequest(context.Background()).Get("/")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Equal(t, int64(2), gotSerial)
+
+	current = newClientCert(3)
+	client.Client.Client().CloseIdleConnections()
+	res, err = client.NewRequest(context.Background()).Get("/")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Equal(t, int64(3), gotSerial, "the rotated certificate is used by the new connection")
+	assert.Equal(t, 2, reloads)
+
+	t.Run("reload error", func(t *testing.T) {
+		client := NewClient("test-client", server.URL,
+			WithTransport(&http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}),
+			WithClientCertReloader(func() (*tls.Certificate, error) {
+				return nil, errors.New("certificate not issued yet")
+			}),
+		)
+
+		_, err := client.NewRequest(context.Background()).Get("/")
+
+		require.ErrorContains(t, err, "httpz: reload client certificate: certificate not issued yet")
+	})
+}
@@ -0,0 +1,75 @@
+package httpz
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"resty.dev/v3"
+)
+
+// maxTrailerDrain is the number of unread body bytes drained on close to reach
+// the trailers, e.g. the padding after a decoded JSON value.
+const maxTrailerDrain = 64 << 10
+
+// Trailer returns the trailers of res, e.g. "Grpc-Status", or nil. They're only
+// known once the body is read to the end, which the decoding does for the
+// responses announcing them in the "Trailer" header, not for a
+// [resty.Request.SetDoNotParseResponse] body still being read.
+func Trailer(res *resty.Response) http.Header {
+	if res == nil || res.RawResponse == nil {
+		return nil
+	}
+	return res.RawResponse.Trailer
+}
+
+// drainTrailers wraps the resty response body decoding middleware, the body of
+// a response announcing trailers is drained on close, so they're received
+// before the next middleware even when the decoder stops at the end of the
+// value, the transport would only drain it in the background.
+func drainTrailers(decode resty.ResponseMiddleware) resty.ResponseMiddleware {
+	return func(c *resty.Client, res *resty.Response) error {
+		if res.RawResponse != nil && len(res.RawResponse.Trailer) > 0 && res.Body != nil {
+			res.Body = &trailerBody{ReadCloser: res.Body}
+		}
+		return decode(c, res)
+	}
+}
+
+type trailerBody struct {
+	io.ReadCloser
+}
+
+func (b *trailerBody) Close() error {
+	_, _ = io.Copy(io.Discard, io.LimitReader(b.ReadCloser, maxTrailerDrain))
+	return b.ReadCloser.Close()
+}
+
+// trailerLogAttr returns the [WithResponseTrailers] trailers of res as a log
+// attribute, if any.
+func trailerLogAttr(cfg *config, res *resty.Response) (slog.Attr, bool) {
+	trailer := Trailer(res)
+	values := make(map[string][]string)
+	for _, name := range cfg.resTrailers {
+		if v := trailer.Values(name); len(v) > 0 {
+			values[http.CanonicalHeaderKey(name)] = v
+		}
+	}
+	return slog.Any("http.response.trailer", values), len(values) > 0
+}
+
+// trailerSpanAttrs returns the [WithResponseTrailers] trailers of res as
+// "http.response.trailer.<name>" span attributes, named like the semconv
+// header ones.
+func trailerSpanAttrs(cfg *config, res *resty.Response) []attribute.KeyValue {
+	trailer := Trailer(res)
+	var attrs []attribute.KeyValue
+	for _, name := range cfg.resTrailers {
+		if v := trailer.Values(name); len(v) > 0 {
+			attrs = append(attrs, attribute.StringSlice("http.response.trailer."+strings.ToLower(name), v))
+		}
+	}
+	return attrs
+}
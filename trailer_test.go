@@ -0,0 +1,59 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestResponseTrailers(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/trailers",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"name":"alice"}` + strings.Repeat(" ", 16<<10)))
+			w.Header().Set("Grpc-Status", "0")
+			w.Header().Set("Grpc-Message", "OK")
+		},
+	})
+	type user struct {
+		Name string `json:"name"`
+	}
+	b := &bytes.Buffer{}
+	rec := tracetest.NewSpanRecorder()
+	client := NewClient("test-client", server.URL,
+		WithLogger(slog.New(slog.NewJSONHandler(b, nil))),
+		WithLogMWEnabled(true),
+		WithTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))),
+		WithOtelMWEnabled(true),
+		WithResponseTrailers([]string{"grpc-status"}),
+	)
+
+	res, err := client.NewRequest(context.Background()).SetResult(&user{}).Get("/test/trailers")
+
+	require.NoError(t, err)
+	assert.Equal(t, &user{Name: "alice"}, res.Result())
+	assert.Equal(t, "0", Trailer(res).Get("Grpc-Status"))
+	assert.Equal(t, "OK", Trailer(res).Get("Grpc-Message"))
+	assert.Contains(t, b.String(), `"http.response.trailer":{"Grpc-Status":["0"]}`)
+	spans := rec.Ended()
+	require.Len(t, spans, 2) // decode and request
+	assert.Equal(t, "HTTP GET", spans[1].Name())
+	assert.Contains(t, spans[1].Attributes(), attribute.StringSlice("http.response.trailer.grpc-status", []string{"0"}))
+
+	t.Run("no trailers", func(t *testing.T) {
+		assert.Nil(t, Trailer(nil))
+	})
+}
@@ -0,0 +1,86 @@
+package httpz
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"slices"
+)
+
+// applyTransportConfig clones the configured transport and applies the
+// transport related options to it, the transport passed by the user (or
+// [http.DefaultTransport]) is never mutated.
+//
+// It's a no-op when no transport option is set or the transport isn't
+// *[http.Transport].
+func applyTransportConfig(cfg *config) {
+	if !cfg.hasTransportOptions() {
+		return
+	}
+
+	t, ok := cfg.transport.(*http.Transport)
+	if !ok {
+		return
+	}
+	t = t.Clone()
+	applyTLSConfig(cfg, t)
+	if cfg.expectContinueTimeout > 0 {
+		t.ExpectContinueTimeout = cfg.expectContinueTimeout
+	}
+	if cfg.maxIdleConnDuration > 0 {
+		t.IdleConnTimeout = cfg.maxIdleConnDuration
+	}
+	if cfg.dnsCacheTTL > 0 {
+		t.DialContext = dnsCacheDialer(t.DialContext, newDNSCache(cfg.dnsCacheTTL, cfg.dnsLookupHost))
+	}
+	if cfg.dialNetwork != "" {
+		t.DialContext = preferNetworkDialer(t.DialContext, cfg.dialNetwork)
+	}
+	cfg.transport = t
+	if cfg.maxConnLifetime > 0 {
+		t.DialContext = lifetimeDialer(t.DialContext, cfg.maxConnLifetime)
+		cfg.transport = &connLifetimeTransport{next: t}
+	}
+}
+
+func (cfg *config) hasTransportOptions() bool {
+	return cfg.tlsMinVersion > 0 ||
+		len(cfg.cipherSuites) > 0 ||
+		cfg.tlsConfigHook != nil ||
+		cfg.certReloader != nil ||
+		cfg.expectContinueTimeout > 0 ||
+		cfg.maxConnLifetime > 0 ||
+		cfg.maxIdleConnDuration > 0 ||
+		cfg.dialNetwork != "" ||
+		cfg.dnsCacheTTL > 0
+}
+
+// preferNetworkDialer dials "tcp" addresses with network, "tcp4" or "tcp6",
+// falling back to "tcp" when it fails, e.g. the host has no address of that
+// family.
+func preferNetworkDialer(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+	network string,
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, n, addr string) (net.Conn, error) {
+		if n != "tcp" {
+			return dial(ctx, n, addr)
+		}
+		conn, err := dial(ctx, network, addr)
+		if err != nil && ctx.Err() == nil {
+			return dial(ctx, n, addr)
+		}
+		return conn, err
+	}
+}
+
+// applyTransportWrappers wraps the transport, the last wrapper first so the
+// first one is the outermost.
+func applyTransportWrappers(cfg *config) {
+	for _, wrap := range slices.Backward(cfg.transportWrappers) {
+		cfg.transport = wrap(cfg.transport)
+	}
+}
@@ -0,0 +1,219 @@
+package httpz
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpectContinueTimeout(t *testing.T) {
+	userTransport := &http.Transport{ExpectContinueTimeout: time.Second}
+	client := NewClient("test-client", "http://localhost",
+		WithTransport(userTransport),
+		WithExpectContinueTimeout(3*time.Second),
+	)
+
+	transport, ok := client.Client.Client().Transport.(*http.Transport)
+
+	require.True(t, ok)
+	assert.Equal(t, 3*time.Second, transport.ExpectContinueTimeout)
+	assert.Equal(t, time.Second, userTransport.ExpectContinueTimeout)
+}
+
+func TestMaxIdleConnDuration(t *testing.T) {
+	client := NewClient("test-client", "http://localhost",
+		WithTransport(&http.Transport{}),
+		WithMaxIdleConnDuration(30*time.Second),
+	)
+
+	transport, ok := client.Client.Client().Transport.(*http.Transport)
+
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, transport.IdleConnTimeout)
+}
+
+func TestMaxConnLifetime(t *testing.T) {
+	var dials atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dials.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	lifetime := 50 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		opts      []option
+		wantDials int32
+	}{
+		{name: "connection reused without lifetime", wantDials: 1},
+		{name: "old connection not reused", opts: []option{WithMaxConnLifetime(lifetime)}, wantDials: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dials.Store(0)
+			client := NewClient("test-client", server.URL, append([]option{
+				WithTransport(&http.Transport{}),
+			}, tt.opts...)...)
+
+			for range 2 {
+				_, err := client.NewRequest(context.Background()).Get("/")
+				require.NoError(t, err)
+			}
+			time.Sleep(lifetime + 50*time.Millisecond)
+			_, err := client.NewRequest(context.Background()).Get("/")
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantDials, dials.Load())
+		})
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransportWrapper(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/wrapped",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	var seen []string
+	wrapper := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				seen = append(seen, name+" "+req.URL.Path)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	client := NewClient("test-client", server.URL,
+		WithTransportWrapper(wrapper("first")),
+		WithTransportWrapper(wrapper("second")),
+	)
+
+	for range 2 {
+		res, err := client.NewRequest(context.Background()).Get("/test/wrapped")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+	}
+	assert.Equal(t, []string{
+		"first /test/wrapped", "second /test/wrapped",
+		"first /test/wrapped", "second /test/wrapped",
+	}, seen)
+}
+
+func TestDialPreferNetwork(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+
+	tests := []struct {
+		name        string
+		opt         option
+		wantNetwork string
+	}{
+		{name: "default", opt: WithDialPreferIPv4(false), wantNetwork: "tcp"},
+		{name: "prefer ipv4", opt: WithDialPreferIPv4(true), wantNetwork: "tcp4"},
+		{name: "prefer ipv6", opt: WithDialPreferIPv6(true), wantNetwork: "tcp6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var networks []string
+			dialer := &net.Dialer{}
+			client := NewClient("test-client", server.URL,
+				WithTransport(&http.Transport{
+					DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+						networks = append(networks, network)
+						return dialer.DialContext(ctx, network, addr)
+					},
+				}),
+				tt.opt,
+			)
+
+			res, err := client.NewRequest(context.Background()).Get("/")
+
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, res.StatusCode())
+			require.NotEmpty(t, networks)
+			assert.Equal(t, tt.wantNetwork, networks[0])
+		})
+	}
+
+	t.Run("falls back when the family is unavailable", func(t *testing.T) {
+		var networks []string
+		dialer := &net.Dialer{}
+		client := NewClient("test-client", server.URL, // 127.0.0.1
+			WithTransport(&http.Transport{
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					networks = append(networks, network)
+					return dialer.DialContext(ctx, network, addr)
+				},
+			}),
+			WithDialPreferIPv6(true),
+		)
+
+		res, err := client.NewRequest(context.Background()).Get("/")
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, []string{"tcp6", "tcp"}, networks)
+	})
+}
+
+func TestHTTPClient(t *testing.T) {
+	server := startTestServer(t, testHandler{
+		method: http.MethodGet,
+		path:   "/test/http-client/slow",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+			w.WriteHeader(http.StatusOK)
+		},
+	})
+	var wrapped atomic.Int32
+	injected := &http.Client{
+		Timeout: 50 * time.Millisecond,
+		Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			wrapped.Add(1)
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+	}
+	client := NewClient("test-client", server.URL,
+		WithHTTPClient(injected),
+		WithTransport(&http.Transport{}), // ignored
+	)
+
+	start := time.Now()
+	_, err := client.NewRequest(context.Background()).Get("/test/http-client/slow")
+
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(1), wrapped.Load(), "the injected client transport is used")
+	assert.NotSame(t, injected, client.Client.Client(), "the injected client is copied")
+	assert.Equal(t, injected.Timeout, client.Client.Client().Timeout)
+}
@@ -0,0 +1,29 @@
+package httpz
+
+import (
+	"errors"
+	"fmt"
+
+	"resty.dev/v3"
+)
+
+// ErrInvalidRequestBody is returned from the verb call, wrapping the validator
+// error, when [WithRequestBodyValidator] rejects the request body.
+var ErrInvalidRequestBody = errors.New("httpz: invalid request body")
+
+// validateRequestBody runs the [WithRequestBodyValidator] validator as the
+// first request middleware, a rejected request is then neither logged nor
+// traced by the interceptors.
+func validateRequestBody(cfg *config) resty.RequestMiddleware {
+	return func(_ *resty.Client, req *resty.Request) error {
+		if cfg.reqBodyValidator == nil || req.Body == nil {
+			return nil
+		}
+
+		if err := cfg.reqBodyValidator(req.Body); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequestBody, err)
+		}
+
+		return nil
+	}
+}
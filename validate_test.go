@@ -0,0 +1,63 @@
+package httpz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestBodyValidator(t *testing.T) {
+	type createUserReq struct {
+		Name string `json:"name"`
+	}
+	errNameRequired := errors.New("name is required")
+	hits := 0
+	server := startTestServer(t, testHandler{
+		method: http.MethodPost,
+		path:   "/test/users",
+		handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.WriteHeader(http.StatusCreated)
+		},
+	})
+	logs := &bytes.Buffer{}
+	client := NewClient("test-client", server.URL,
+		WithPaths(map[string]string{"createUser": "/test/users"}),
+		WithLogger(slog.New(slog.NewJSONHandler(logs, nil))),
+		WithLogMWEnabled(true),
+		WithRequestBodyValidator(func(body any) error {
+			if req, ok := body.(*createUserReq); ok && req.Name == "" {
+				return errNameRequired
+			}
+			return nil
+		}),
+	)
+
+	t.Run("invalid body", func(t *testing.T) {
+		_, err := client.NewRequest(context.Background()).
+			SetBody(&createUserReq{}).
+			Post(client.GetPath("createUser"))
+
+		require.ErrorIs(t, err, ErrInvalidRequestBody)
+		require.ErrorIs(t, err, errNameRequired)
+		assert.Zero(t, hits)
+		assert.NotContains(t, logs.String(), "[HTTPZ][OUTGOING REQUEST]")
+	})
+
+	t.Run("valid body", func(t *testing.T) {
+		res, err := client.NewRequest(context.Background()).
+			SetBody(&createUserReq{Name: "alice"}).
+			Post(client.GetPath("createUser"))
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, res.StatusCode())
+		assert.Equal(t, 1, hits)
+		assert.Contains(t, logs.String(), "[HTTPZ][OUTGOING REQUEST]")
+	})
+}